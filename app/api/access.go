@@ -0,0 +1,178 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+//access contains what the client of a request is allowed to do, it is stored in the request context by authorize
+type access struct {
+	role Role
+	// models contains the granted room models, admins may access all models
+	models map[uint]bool
+}
+
+//accessKey is the key the access is stored under in the request context
+const accessKey = "access"
+
+//fullAccess applies if authentication is disabled
+var fullAccess = &access{role: RoleAdmin}
+
+//accessOf returns the access of the client of the request
+func accessOf(c *gin.Context) *access {
+	if a, ok := c.Get(accessKey); ok {
+		return a.(*access)
+	}
+	return fullAccess
+}
+
+//all reports whether every room model may be accessed
+func (a *access) all() bool {
+	return a.role.Includes(RoleAdmin)
+}
+
+//permits reports whether the room model may be accessed
+func (a *access) permits(modelID uint) bool {
+	return a.all() || a.models[modelID]
+}
+
+//modelIDs returns the ids of the granted room models in ascending order
+func (a *access) modelIDs() []uint {
+	ids := make([]uint, 0, len(a.models))
+	for id := range a.models {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+//scope restricts the query to the rows whose column references a granted room model
+func (a *access) scope(q *gorm.DB, column string) *gorm.DB {
+	if a.all() {
+		return q
+	}
+	return q.Where(column+" IN (?)", a.modelIDs())
+}
+
+//readOnlyRoutes are the routes which change nothing although they are not requested with GET
+var readOnlyRoutes = map[string]bool{
+	"/sensors/:id/anomalies/preview": true,
+}
+
+//authorize is the middleware loading the user of the authenticated principal. Principals listed in ADMIN_USERS are
+//admins without being registered, every other principal has to be registered as user. Only technicians and admins
+//may send requests other than GET, except to the readOnlyRoutes.
+func (a *authenticator) authorize(c *gin.Context) {
+	p, ok := c.Get(principalKey)
+	if !ok {
+		return
+	}
+
+	acc, err := a.access(p.(*Principal).Name)
+	if err == nil && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead &&
+		!readOnlyRoutes[c.FullPath()] && !acc.role.Includes(RoleTechnician) {
+		err = fmt.Errorf("Role %s is not allowed to change anything.", acc.role)
+	}
+	if err != nil {
+		c.String(http.StatusForbidden, AsJSON(gin.H{"error": err.Error()}))
+		c.Abort()
+		return
+	}
+	c.Set(accessKey, acc)
+}
+
+func (a *authenticator) access(name string) (*access, error) {
+	if a.admins[name] {
+		return fullAccess, nil
+	}
+
+	var u User
+	DB.Preload("Grants").Where("name = ?", name).First(&u)
+	if u.ID == 0 {
+		return nil, fmt.Errorf("User %s is not registered.", name)
+	}
+
+	acc := &access{role: u.Role, models: make(map[uint]bool, len(u.Grants))}
+	for _, g := range u.Grants {
+		acc.models[g.RoomModelID] = true
+	}
+	return acc, nil
+}
+
+//requireRole returns a middleware rejecting clients without the role
+func requireRole(role Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if acc := accessOf(c); !acc.role.Includes(role) {
+			c.String(http.StatusForbidden, AsJSON(gin.H{"error": fmt.Sprintf("Role %s is required.", role)}))
+			c.Abort()
+		}
+	}
+}
+
+//restrictTo returns a middleware rejecting requests to an entity of a room model which is not granted; modelOf
+//returns the id of the room model the entity with the id path parameter belongs to or 0 if it does not exist, in
+//which case the handler responds
+func restrictTo(modelOf func(id uint) uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		acc := accessOf(c)
+		if acc.all() || c.Param("id") == "" {
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			return
+		}
+		if modelID := modelOf(uint(id)); modelID != 0 && !acc.permits(modelID) {
+			c.String(http.StatusForbidden, AsJSON(gin.H{"error": fmt.Sprintf("Model %d is not granted.", modelID)}))
+			c.Abort()
+		}
+	}
+}
+
+func findRoomModel(modelID uint) uint {
+	return modelID
+}
+
+//findRoomModelOfSensor returns the id of the room model the sensor belongs to or 0 if the sensor does not exist
+func findRoomModelOfSensor(sensorID uint) uint {
+	var s Sensor
+	DB.First(&s, sensorID)
+	return s.RoomModelID
+}
+
+//findRoomModelOfFloor returns the id of the room model the floor belongs to or 0 if the floor does not exist
+func findRoomModelOfFloor(floorID uint) uint {
+	var f Floor
+	DB.First(&f, floorID)
+	return f.RoomModelID
+}
+
+//findRoomModelOfMaintenanceWindow returns the id of the room model the window applies to or 0 if it does not exist
+func findRoomModelOfMaintenanceWindow(windowID uint) uint {
+	var w MaintenanceWindow
+	DB.First(&w, windowID)
+	if w.RoomModelID != nil {
+		return *w.RoomModelID
+	}
+	if w.SensorID != nil {
+		return findRoomModelOfSensor(*w.SensorID)
+	}
+	return 0
+}
+
+//findRoomModelOfReplay returns the id of the room model the replay plays back or 0 if it does not exist
+func findRoomModelOfReplay(replayID uint) uint {
+	replays.Lock()
+	defer replays.Unlock()
+
+	if r, ok := replays.entries[replayID]; ok {
+		return r.RoomModelID
+	}
+	return 0
+}
@@ -0,0 +1,386 @@
+package api
+
+import (
+	. "github.com/vi-sense/vi-sense/app/model"
+	"math"
+	"time"
+)
+
+type Anomaly struct {
+	Type        AnomalyType `json:"type"`
+	StartData   *Data       `json:"start_data"`
+	EndData     *Data       `json:"end_data"`
+	PeakData    *Data       `json:"peak_data"`
+	Maintenance bool        `json:"maintenance,omitempty"`
+	// bound is the violated bound at the time of the peak
+	bound float64
+}
+
+//Severity returns the deviation of the peak from the violated bound relative to the bound
+func (a *Anomaly) Severity() float64 {
+	var deviation float64
+	switch a.Type {
+	case BelowLowerLimit, AboveUpperLimit:
+		deviation = math.Abs(a.PeakData.Value - a.bound)
+	case UpwardGradient, DownwardGradient:
+		deviation = math.Abs(a.PeakData.Gradient) - math.Abs(a.bound)
+	}
+
+	if a.bound == 0 {
+		return round(deviation)
+	}
+	return round(deviation / math.Abs(a.bound))
+}
+
+type AnomalyType string
+
+const (
+	UpwardGradient   AnomalyType = "High Upward Gradient"
+	DownwardGradient AnomalyType = "High Downward Gradient"
+	AboveUpperLimit  AnomalyType = "Above Upper Limit"
+	BelowLowerLimit  AnomalyType = "Below Lower Limit"
+)
+
+//MaintenanceMode defines how readings within a MaintenanceWindow are treated by the anomaly detection
+type MaintenanceMode string
+
+const (
+	//SuppressMaintenance skips readings within maintenance windows
+	SuppressMaintenance MaintenanceMode = "suppress"
+	//TagMaintenance keeps anomalies which started within maintenance windows but marks them
+	TagMaintenance MaintenanceMode = "tag"
+	//IgnoreMaintenance evaluates all readings regardless of any maintenance windows
+	IgnoreMaintenance MaintenanceMode = "ignore"
+)
+
+//anomalyConfig contains everything besides the bounds of the sensor itself which influences the anomaly detection
+type anomalyConfig struct {
+	// versions contains the configurations of the sensor in chronological order, the sensor's bounds apply to
+	// readings no version applies to
+	versions  []SensorConfig
+	windows   []MaintenanceWindow
+	schedules []BoundSchedule
+	location  *time.Location
+	mode      MaintenanceMode
+}
+
+//loadAnomalyConfig loads the configuration history, the maintenance windows and the bound schedules of a sensor as
+//well as the time zone of its site
+func loadAnomalyConfig(s *Sensor, mode MaintenanceMode) anomalyConfig {
+	return loadAnomalyConfigs([]Sensor{*s}, mode)[s.ID]
+}
+
+//loadAnomalyConfigs loads the configurations of all sensors with one query per kind of setting
+func loadAnomalyConfigs(sensors []Sensor, mode MaintenanceMode) map[uint]anomalyConfig {
+	ids := make([]uint, len(sensors))
+	modelIDs := make([]uint, 0)
+	r := make(map[uint]anomalyConfig, len(sensors))
+	for i := range sensors {
+		ids[i] = sensors[i].ID
+		if _, ok := r[sensors[i].ID]; !ok {
+			modelIDs = append(modelIDs, sensors[i].RoomModelID)
+		}
+		r[sensors[i].ID] = anomalyConfig{versions: make([]SensorConfig, 0), windows: make([]MaintenanceWindow, 0),
+			schedules: make([]BoundSchedule, 0), location: time.UTC, mode: mode}
+	}
+
+	var models []RoomModel
+	DB.Where("id IN (?)", modelIDs).Find(&models)
+	locations := make(map[uint]*time.Location, len(models))
+	for i := range models {
+		locations[models[i].ID] = models[i].TimeLocation()
+	}
+
+	var versions []SensorConfig
+	DB.Where("sensor_id IN (?)", ids).Order("id").Find(&versions)
+	var schedules []BoundSchedule
+	DB.Where("sensor_id IN (?)", ids).Order("id").Find(&schedules)
+	var windows []MaintenanceWindow
+	DB.Where("sensor_id IN (?) OR room_model_id IN (?)", ids, modelIDs).Find(&windows)
+
+	for i := range sensors {
+		s := &sensors[i]
+		config := r[s.ID]
+		if l, ok := locations[s.RoomModelID]; ok {
+			config.location = l
+		}
+		for _, v := range versions {
+			if v.SensorID == s.ID {
+				config.versions = append(config.versions, v)
+			}
+		}
+		for _, sc := range schedules {
+			if sc.SensorID == s.ID {
+				config.schedules = append(config.schedules, sc)
+			}
+		}
+		for _, w := range windows {
+			if (w.SensorID != nil && *w.SensorID == s.ID) || (w.RoomModelID != nil && *w.RoomModelID == s.RoomModelID) {
+				config.windows = append(config.windows, w)
+			}
+		}
+		r[s.ID] = config
+	}
+	return r
+}
+
+//bounds are the limits which apply to a single reading
+type bounds struct {
+	lower    *float64
+	upper    *float64
+	gradient *float64
+}
+
+//anomalyDetector evaluates the data of a single sensor reading by reading; data has to be pushed in chronological order
+type anomalyDetector struct {
+	sensor    *Sensor
+	config    anomalyConfig
+	current   [4]*Anomaly
+	anomalies []Anomaly
+}
+
+func newAnomalyDetector(s *Sensor, config anomalyConfig) *anomalyDetector {
+	if config.location == nil {
+		config.location = time.UTC
+	}
+	return &anomalyDetector{sensor: s, config: config, anomalies: make([]Anomaly, 0)}
+}
+
+//push evaluates the next reading; the passed data must not be modified afterwards as anomalies reference it
+func (d *anomalyDetector) push(data *Data) {
+	b := d.boundsAt(data)
+	maintenance := d.inMaintenance(data)
+
+	if maintenance && d.config.mode == SuppressMaintenance {
+		// readings during maintenance end all running anomalies
+		for i := range d.current {
+			d.track(i, "", nil, false, data, nil, false)
+		}
+		return
+	}
+
+	d.track(0, BelowLowerLimit, b.lower, b.lower != nil && data.Value < *b.lower, data, func(n, p *Data) bool {
+		return n.Value < p.Value
+	}, maintenance)
+
+	d.track(1, AboveUpperLimit, b.upper, b.upper != nil && data.Value > *b.upper, data, func(n, p *Data) bool {
+		return n.Value > p.Value
+	}, maintenance)
+
+	d.track(2, UpwardGradient, b.gradient, b.gradient != nil && data.Gradient >= 0 && data.Gradient > *b.gradient,
+		data, func(n, p *Data) bool {
+			return n.Gradient > p.Gradient
+		}, maintenance)
+
+	d.track(3, DownwardGradient, b.gradient, b.gradient != nil && data.Gradient < 0 && data.Gradient < -*b.gradient,
+		data, func(n, p *Data) bool {
+			return n.Gradient < p.Gradient
+		}, maintenance)
+}
+
+//boundsAt returns the bounds of the first schedule applying to the reading, unset bounds fall back to the ones of
+//the sensor's configuration in force at the reading's date
+func (d *anomalyDetector) boundsAt(data *Data) bounds {
+	b := bounds{lower: d.sensor.LowerBound, upper: d.sensor.UpperBound, gradient: d.sensor.GradientBound}
+	for i := range d.config.versions {
+		if v := &d.config.versions[i]; v.Applies(data.Date.Time) {
+			b = bounds{lower: v.LowerBound, upper: v.UpperBound, gradient: v.GradientBound}
+			break
+		}
+	}
+
+	for i := range d.config.schedules {
+		sc := &d.config.schedules[i]
+		if !sc.Applies(data.Date.Time, d.config.location) {
+			continue
+		}
+
+		if sc.LowerBound != nil {
+			b.lower = sc.LowerBound
+		}
+		if sc.UpperBound != nil {
+			b.upper = sc.UpperBound
+		}
+		if sc.GradientBound != nil {
+			b.gradient = sc.GradientBound
+		}
+		break
+	}
+
+	return b
+}
+
+//track starts, continues or ends the anomaly of the given slot depending on whether the bound is violated
+func (d *anomalyDetector) track(i int, t AnomalyType, bound *float64, violated bool, data *Data,
+	isPeak func(n, p *Data) bool, maintenance bool) {
+	if violated {
+		// new anomaly occurred
+		if d.current[i] == nil {
+			d.current[i] = &Anomaly{Type: t, StartData: data, PeakData: data, Maintenance: maintenance, bound: *bound}
+			// anomaly goes on
+		} else {
+			// new peak value
+			if isPeak(data, d.current[i].PeakData) {
+				d.current[i].PeakData = data
+				d.current[i].bound = *bound
+			}
+			d.current[i].EndData = data
+		}
+		// anomaly ended
+	} else if d.current[i] != nil {
+		d.anomalies = append(d.anomalies, *d.current[i])
+		d.current[i] = nil
+	}
+}
+
+func (d *anomalyDetector) inMaintenance(data *Data) bool {
+	if d.config.mode == IgnoreMaintenance {
+		return false
+	}
+
+	for i := range d.config.windows {
+		if d.config.windows[i].Covers(data.Date.Time) {
+			return true
+		}
+	}
+	return false
+}
+
+//step evaluates the next reading like push and returns the anomalies it started and ended; ended anomalies are
+//not kept, so a detector which is only stepped does not grow
+func (d *anomalyDetector) step(data *Data) (started []Anomaly, ended []Anomaly) {
+	before := d.current
+	n := len(d.anomalies)
+	d.push(data)
+
+	ended = append(ended, d.anomalies[n:]...)
+	d.anomalies = d.anomalies[:n]
+	for i, a := range d.current {
+		if a != nil && a != before[i] {
+			started = append(started, *a)
+		}
+	}
+	return started, ended
+}
+
+//forget drops the ended anomalies and copies the readings of the running ones, so the data pushed so far can be freed
+func (d *anomalyDetector) forget() {
+	d.anomalies = make([]Anomaly, 0)
+	for _, a := range d.current {
+		if a == nil {
+			continue
+		}
+		for _, p := range []**Data{&a.StartData, &a.EndData, &a.PeakData} {
+			if *p != nil {
+				data := **p
+				*p = &data
+			}
+		}
+	}
+}
+
+//active reports whether any anomaly is still going on at the last pushed reading
+func (d *anomalyDetector) active() bool {
+	for _, a := range d.current {
+		if a != nil {
+			return true
+		}
+	}
+	return false
+}
+
+//finish returns all ended anomalies followed by those which are still going on
+func (d *anomalyDetector) finish() []Anomaly {
+	anomalies := d.anomalies
+	for _, a := range d.current {
+		if a != nil {
+			anomalies = append(anomalies, *a)
+		}
+	}
+	return anomalies
+}
+
+//findAnomalies evaluates the passed data of a sensor in chronological order
+func findAnomalies(s *Sensor, r []Data, config anomalyConfig) []Anomaly {
+	d := newAnomalyDetector(s, config)
+	for i := range r {
+		d.push(&r[i])
+	}
+	return d.finish()
+}
+
+//statusLookback is the period before a reading of a sensor which is evaluated to find the anomalies going on at it;
+//anomalies which started earlier are reported as starting at the first evaluated reading
+const statusLookback = 24 * time.Hour
+
+//currentAnomalies returns the anomalies going on at the passed latest reading of every sensor, sensors without
+//readings have none; only the readings within statusLookback before the latest one are evaluated
+func currentAnomalies(sensors []Sensor, latest []Data, mode MaintenanceMode) [][]Anomaly {
+	configs := loadAnomalyConfigs(sensors, mode)
+	r := make([][]Anomaly, len(sensors))
+	for i := range sensors {
+		r[i] = make([]Anomaly, 0)
+		if latest[i].ID == 0 {
+			continue
+		}
+
+		data := make([]Data, 0)
+		DB.Where("sensor_id = ? AND date >= ? AND date <= ?", sensors[i].ID,
+			latest[i].Date.Add(-statusLookback), latest[i].Date.Time).Order("date").Find(&data)
+
+		d := newAnomalyDetector(&sensors[i], configs[sensors[i].ID])
+		for j := range data {
+			d.push(&data[j])
+		}
+		for _, a := range d.current {
+			if a != nil {
+				r[i] = append(r[i], *a)
+			}
+		}
+	}
+	return r
+}
+
+//AnomalySummary summarizes the anomalies of a single sensor
+type AnomalySummary struct {
+	Count         int         `json:"count"`
+	WorstSeverity float64     `json:"worst_severity"`
+	WorstType     AnomalyType `json:"worst_type"`
+	// Active is true if an anomaly is still going on at the latest reading of the sensor
+	Active bool `json:"active"`
+}
+
+//SensorAnomalies contains the anomalies of a single sensor together with their summary
+type SensorAnomalies struct {
+	SensorID  uint           `json:"sensor_id"`
+	Summary   AnomalySummary `json:"summary"`
+	Anomalies []Anomaly      `json:"anomalies"`
+}
+
+func summarizeAnomalies(anomalies []Anomaly, active bool) AnomalySummary {
+	summary := AnomalySummary{Count: len(anomalies), Active: active}
+	for i := range anomalies {
+		if severity := anomalies[i].Severity(); summary.WorstType == "" || severity > summary.WorstSeverity {
+			summary.WorstSeverity = severity
+			summary.WorstType = anomalies[i].Type
+		}
+	}
+	return summary
+}
+
+//findMaintenanceWindows returns all windows which apply to the sensor itself or to its room model
+func findMaintenanceWindows(s *Sensor) []MaintenanceWindow {
+	w := make([]MaintenanceWindow, 0)
+	DB.Where("sensor_id = ? OR room_model_id = ?", s.ID, s.RoomModelID).Find(&w)
+	return w
+}
+
+func parseMaintenanceMode(s string) (MaintenanceMode, error) {
+	switch MaintenanceMode(s) {
+	case "":
+		return SuppressMaintenance, nil
+	case SuppressMaintenance, TagMaintenance, IgnoreMaintenance:
+		return MaintenanceMode(s), nil
+	}
+	return "", &ParamParseError{Param: "maintenance", Value: s}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
@@ -9,73 +10,253 @@ import (
 	"github.com/s12i/gin-throttle"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/vi-sense/vi-sense/app/config"
 	"github.com/vi-sense/vi-sense/app/docs"
+	. "github.com/vi-sense/vi-sense/app/model"
 	"net/http"
-	"os"
+	"path/filepath"
+	"strings"
 )
 
-func GetEnv(key string, defVal string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-
-	fmt.Printf("Could not read %s returning default value %s.\n", key, defVal)
-	return defVal
-}
-
 //@title vi-sense BIM API
 //@version 0.1.9
 //@description This API provides information about 3D room models with associated sensors and their data.
 
 //@BasePath /
 
-//SetupRouter initializes all available routes / endpoints and the access to static files
+//@securityDefinitions.apikey ApiKeyAuth
+//@in header
+//@name X-API-Key
+
+//@securityDefinitions.apikey BearerAuth
+//@in header
+//@name Authorization
+
+//SetupRouter initializes the router with the configuration from the CONFIG_FILE and the environment
 func SetupRouter() *gin.Engine {
+	c, err := config.Load(nil)
+	if err != nil {
+		fmt.Println("[!]", err)
+		panic("[!] invalid configuration")
+	}
+	return NewRouter(c)
+}
 
-	docs.SwaggerInfo.Host = fmt.Sprintf("%s:%s", GetEnv("HOST", "localhost"), GetEnv("PORT", "8080"))
-	docs.SwaggerInfo.Schemes = []string{GetEnv("SCHEME", "http")}
-	r := gin.Default()
+//NewRouter initializes all available routes / endpoints and the access to static files
+func NewRouter(c *config.Config) *gin.Engine {
 
-	if GetEnv("PRODUCTION", "false") == "true" {
-		r.Use(gzip.Gzip(gzip.BestSpeed))
+	docs.SwaggerInfo.Host = fmt.Sprintf("%s:%d", c.Host, c.Port)
+	docs.SwaggerInfo.Schemes = []string{c.Scheme}
+	r := gin.New()
+	r.Use(gin.LoggerWithFormatter(redactedLogFormatter), gin.Recovery())
+
+	if c.Production {
+		compress := gzip.Gzip(gzip.BestSpeed)
+		r.Use(func(c *gin.Context) {
+			// event streams have to reach the client unbuffered
+			if !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+				compress(c)
+			}
+		})
 		fmt.Println("[i] Using gzip.")
 	}
 
 	//to limit the number of requests per second
-	r.Use(middleware.Throttle(100, 100))
+	r.Use(middleware.Throttle(c.Throttle.Rate, c.Throttle.Burst))
 
-	// cors settings
-	/*r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:8081", "https://visense.f4.htw-berlin.de"},
-		AllowMethods:     []string{"GET", "PATCH"},
-		AllowHeaders:     []string{"Origin"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge: 12 * time.Hour,
-	}))*/
-	r.Use(cors.Default())
+	// cors settings, browsers have to be allowed to send the credentials
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AddAllowHeaders("Authorization", "X-API-Key")
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			corsConfig.AllowAllOrigins = true
+		}
+	}
+	if !corsConfig.AllowAllOrigins {
+		corsConfig.AllowOrigins = c.CORS.AllowOrigins
+	}
+	r.Use(cors.New(corsConfig))
 
-	r.Static("/files", "/sample-data/models/")
+	// the api is only open if that was requested explicitly, a broken configuration must not open it
+	if c.Auth.Disabled {
+		fmt.Println("[!] AUTH_DISABLED is set, authentication is disabled.")
+	} else {
+		authn, err := newAuthenticator(c.Auth, c.Server.ClientCAFile != "")
+		if err == nil && authn == nil {
+			err = errors.New("no credentials are configured")
+		}
+		if err != nil {
+			fmt.Println("[!]", err)
+			panic("[!] failed to configure authentication")
+		}
+		r.Use(authn.authenticate, authn.authorize)
+		fmt.Println("[i] Authentication enabled.")
+	}
+
+	// files of the sample data, uploaded files are served by the models group
+	r.Static("/files", filepath.Join(c.DataDir, "models"))
+	limits := fileLimits{ModelFileKind: c.Storage.MaxModelFileSize, ImageFileKind: c.Storage.MaxImageFileSize}
+	importLimit, importDataLimit := c.Storage.MaxImportFileSize, c.Storage.MaxImportDataSize
 
 	// Ping test
 	r.GET("/ping", func(c *gin.Context) {
 		c.String(http.StatusOK, "pong")
 	})
 
-	models := r.Group("/models")
+	models := r.Group("/models", restrictTo(findRoomModel))
 	{
 		models.GET("", func(c *gin.Context) {
-			c.String(QueryRoomModels())
+			c.String(QueryRoomModels(c))
 		})
 		models.GET(":id", func(c *gin.Context) {
 			c.String(QueryRoomModel(c))
 		})
+
+		models.GET(":id/anomalies", func(c *gin.Context) {
+			c.String(QueryModelAnomalies(c))
+		})
+
+		models.GET(":id/data", func(c *gin.Context) {
+			c.String(QueryModelData(c))
+		})
+
+		models.GET(":id/aggregates", func(c *gin.Context) {
+			c.String(QueryModelAggregates(c))
+		})
+
+		models.POST("", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(CreateRoomModel(c))
+		})
+
+		models.PUT(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(UpdateRoomModel(c))
+		})
+
+		models.PATCH(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(PatchRoomModel(c))
+		})
+
+		models.DELETE(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(DeleteRoomModel(c))
+		})
+
+		models.GET(":id/meshes", func(c *gin.Context) {
+			c.String(QueryModelMeshes(c))
+		})
+
+		models.GET(":id/files", func(c *gin.Context) {
+			c.String(QueryModelFiles(c))
+		})
+
+		models.POST(":id/files", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(UploadModelFile(c, limits))
+		})
+
+		models.GET(":id/files/:file_id", DownloadModelFile)
+
+		models.GET(":id/export", ExportRoomModel)
+
+		models.GET(":id/stream", StreamRoomModel)
+
+		models.DELETE(":id/files/:file_id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(DeleteModelFile(c))
+		})
+
+		models.GET(":id/floors", func(c *gin.Context) {
+			c.String(QueryFloors(c))
+		})
+
+		models.POST(":id/floors", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(CreateFloor(c))
+		})
+
+		models.GET(":id/assets", func(c *gin.Context) {
+			c.String(QueryAssets(c))
+		})
+
+		models.POST(":id/assets", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(CreateAsset(c))
+		})
+	}
+
+	assets := r.Group("/assets", restrictTo(findRoomModelOfAsset))
+	{
+		assets.GET(":id", func(c *gin.Context) {
+			c.String(QueryAsset(c))
+		})
+
+		assets.PUT(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(UpdateAsset(c))
+		})
+
+		assets.DELETE(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(DeleteAsset(c))
+		})
+
+		assets.GET(":id/anomalies", func(c *gin.Context) {
+			c.String(QueryAssetAnomalies(c))
+		})
+	}
+
+	floors := r.Group("/floors", restrictTo(findRoomModelOfFloor))
+	{
+		floors.GET(":id", func(c *gin.Context) {
+			c.String(QueryFloor(c))
+		})
+
+		floors.PUT(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(UpdateFloor(c))
+		})
+
+		floors.DELETE(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(DeleteFloor(c))
+		})
+
+		floors.POST(":id/rooms", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(CreateRoom(c))
+		})
+
+		floors.GET(":id/anomalies", func(c *gin.Context) {
+			c.String(QueryFloorAnomalies(c))
+		})
+
+		floors.GET(":id/aggregates", func(c *gin.Context) {
+			c.String(QueryFloorAggregates(c))
+		})
 	}
 
-	sensors := r.Group("/sensors")
+	rooms := r.Group("/rooms", restrictTo(findRoomModelOfRoom))
+	{
+		rooms.GET(":id", func(c *gin.Context) {
+			c.String(QueryRoom(c))
+		})
+
+		rooms.PUT(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(UpdateRoom(c))
+		})
+
+		rooms.DELETE(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(DeleteRoom(c))
+		})
+
+		rooms.GET(":id/anomalies", func(c *gin.Context) {
+			c.String(QueryRoomAnomalies(c))
+		})
+
+		rooms.GET(":id/aggregates", func(c *gin.Context) {
+			c.String(QueryRoomAggregates(c))
+		})
+	}
+
+	// models can not be imported below /models as the path would conflict with the model id
+	r.POST("/import", requireRole(RoleAdmin), func(c *gin.Context) {
+		c.String(ImportRoomModel(c, importLimit, importDataLimit))
+	})
+
+	sensors := r.Group("/sensors", restrictTo(findRoomModelOfSensor))
 	{
 		sensors.GET("", func(c *gin.Context) {
-			c.String(QuerySensors())
+			c.String(QuerySensors(c))
 		})
 
 		sensors.GET(":id", func(c *gin.Context) {
@@ -86,13 +267,128 @@ func SetupRouter() *gin.Engine {
 			c.String(QuerySensorData(c))
 		})
 
+		sensors.POST(":id/data", func(c *gin.Context) {
+			c.String(IngestSensorData(c))
+		})
+
 		sensors.GET(":id/anomalies", func(c *gin.Context) {
 			c.String(QueryAnomalies(c))
 		})
 
+		sensors.POST(":id/anomalies/preview", func(c *gin.Context) {
+			c.String(PreviewAnomalies(c))
+		})
+
+		sensors.GET(":id/suggested-bounds", func(c *gin.Context) {
+			c.String(QuerySuggestedBounds(c))
+		})
+
+		sensors.POST("", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(CreateSensor(c))
+		})
+
 		sensors.PATCH(":id", func(c *gin.Context) {
 			c.String(PatchSensor(c))
 		})
+
+		sensors.DELETE(":id", requireRole(RoleAdmin), func(c *gin.Context) {
+			c.String(DeleteSensor(c))
+		})
+
+		sensors.GET(":id/configs", func(c *gin.Context) {
+			c.String(QuerySensorConfigs(c))
+		})
+
+		sensors.GET(":id/schedules", func(c *gin.Context) {
+			c.String(QueryBoundSchedules(c))
+		})
+
+		sensors.POST(":id/schedules", func(c *gin.Context) {
+			c.String(CreateBoundSchedule(c))
+		})
+
+		sensors.DELETE(":id/schedules/:schedule_id", func(c *gin.Context) {
+			c.String(DeleteBoundSchedule(c))
+		})
+	}
+
+	maintenance := r.Group("/maintenance", restrictTo(findRoomModelOfMaintenanceWindow))
+	{
+		maintenance.GET("", func(c *gin.Context) {
+			c.String(QueryMaintenanceWindows(c))
+		})
+
+		maintenance.GET("upcoming", func(c *gin.Context) {
+			c.String(QueryUpcomingMaintenance(c))
+		})
+
+		maintenance.POST("", func(c *gin.Context) {
+			c.String(CreateMaintenanceWindow(c))
+		})
+
+		maintenance.DELETE(":id", func(c *gin.Context) {
+			c.String(DeleteMaintenanceWindow(c))
+		})
+	}
+
+	r.GET("/live", LiveUpdates)
+
+	replay := r.Group("/replays", restrictTo(findRoomModelOfReplay))
+	{
+		replay.GET("", func(c *gin.Context) {
+			c.String(QueryReplays(c))
+		})
+
+		replay.POST("", func(c *gin.Context) {
+			c.String(StartReplay(c))
+		})
+
+		replay.GET(":id", func(c *gin.Context) {
+			c.String(QueryReplay(c))
+		})
+
+		replay.POST(":id/pause", func(c *gin.Context) {
+			c.String(PauseReplay(c))
+		})
+
+		replay.POST(":id/resume", func(c *gin.Context) {
+			c.String(ResumeReplay(c))
+		})
+
+		replay.POST(":id/stop", func(c *gin.Context) {
+			c.String(StopReplay(c))
+		})
+	}
+
+	r.GET("/audit", requireRole(RoleAdmin), func(c *gin.Context) {
+		c.String(QueryAuditEntries(c))
+	})
+
+	r.GET("/me", func(c *gin.Context) {
+		c.String(QueryMe(c))
+	})
+
+	users := r.Group("/users", requireRole(RoleAdmin))
+	{
+		users.GET("", func(c *gin.Context) {
+			c.String(QueryUsers(c))
+		})
+
+		users.POST("", func(c *gin.Context) {
+			c.String(CreateUser(c))
+		})
+
+		users.GET(":id", func(c *gin.Context) {
+			c.String(QueryUser(c))
+		})
+
+		users.PUT(":id", func(c *gin.Context) {
+			c.String(UpdateUser(c))
+		})
+
+		users.DELETE(":id", func(c *gin.Context) {
+			c.String(DeleteUser(c))
+		})
 	}
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+)
+
+//AssetStatus contains an asset together with the latest value and the current anomalies of each of its sensors
+type AssetStatus struct {
+	Asset   Asset          `json:"asset"`
+	Sensors []SensorStatus `json:"sensors"`
+}
+
+//SensorStatus contains a sensor with its latest data and the anomalies which are going on at that reading
+type SensorStatus struct {
+	Sensor          Sensor    `json:"sensor"`
+	ActiveAnomalies []Anomaly `json:"active_anomalies"`
+}
+
+//QueryAssets godoc
+//@Summary Query assets
+//@Description Query all equipment assets of a room model with their sensors.
+//@Tags assets
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Success 200 {array} model.Asset
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/assets [get]
+func QueryAssets(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	r := make([]Asset, 0)
+	DB.Preload("Sensors").Where("room_model_id = ?", q.ID).Order("id").Find(&r)
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//CreateAsset godoc
+//@Summary Create asset
+//@Description Creates a new equipment asset inside a room model.
+//@Description The mesh id has to exist in the model's uploaded glTF/GLB file unless force is set.
+//@Tags assets
+//@Accept json
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param asset body model.Asset true "Asset"
+//@Param force query bool false "Accept a mesh id which does not exist in the model's glTF/GLB file"
+//@Success 201 {object} model.Asset
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/assets [post]
+func CreateAsset(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	var a Asset
+	if err := c.ShouldBindJSON(&a); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	a.RoomModelID = q.ID
+
+	return saveAsset(c, &a, 0, http.StatusCreated)
+}
+
+//QueryAsset godoc
+//@Summary Query asset
+//@Description Query a single asset together with its sensors, their latest values and their current anomalies.
+//@Description An anomaly is current if it is still going on at the sensor's latest reading, only the readings of the
+//@Description 24 hours before it are evaluated.
+//@Tags assets
+//@Produce json
+//@Param id path int true "Asset ID"
+//@Param maintenance query string false "Handling of maintenance windows [suppress, tag, ignore]"
+//@Success 200 {object} AssetStatus
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /assets/{id} [get]
+func QueryAsset(c *gin.Context) (int, string) {
+	var a Asset
+	id := c.Param("id")
+	DB.Preload("Sensors", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&a, id)
+	if a.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Asset %s not found.", id)})
+	}
+
+	mode, err := parseMaintenanceMode(c.Query("maintenance"))
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	sensors := a.Sensors
+	a.Sensors = nil
+	r := AssetStatus{Asset: a, Sensors: make([]SensorStatus, len(sensors))}
+
+	latest := make([]Data, len(sensors))
+	for i := range sensors {
+		latest[i] = findLatestData(&sensors[i])
+	}
+
+	anomalies := currentAnomalies(sensors, latest, mode)
+	for i := range sensors {
+		sensors[i].LatestData = latest[i]
+		r.Sensors[i].Sensor = sensors[i]
+		r.Sensors[i].ActiveAnomalies = anomalies[i]
+	}
+
+	return http.StatusOK, AsJSON(&r)
+}
+
+//UpdateAsset godoc
+//@Summary Update asset
+//@Description Replaces all editable fields of an asset, sensors are attached via the sensor endpoints.
+//@Description The mesh id has to exist in the model's uploaded glTF/GLB file unless force is set.
+//@Tags assets
+//@Accept json
+//@Produce json
+//@Param id path int true "Asset ID"
+//@Param asset body model.Asset true "Asset"
+//@Param force query bool false "Accept a mesh id which does not exist in the model's glTF/GLB file"
+//@Success 200 {object} model.Asset
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /assets/{id} [put]
+func UpdateAsset(c *gin.Context) (int, string) {
+	var current Asset
+	id := c.Param("id")
+	DB.First(&current, id)
+	if current.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Asset %s not found.", id)})
+	}
+
+	var a Asset
+	if err := c.ShouldBindJSON(&a); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	a.RoomModelID = current.RoomModelID
+
+	return saveAsset(c, &a, current.ID, http.StatusOK)
+}
+
+//DeleteAsset godoc
+//@Summary Delete asset
+//@Description Deletes an asset, its sensors are detached but kept.
+//@Tags assets
+//@Param id path int true "Asset ID"
+//@Success 204 {string} string "no content"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /assets/{id} [delete]
+func DeleteAsset(c *gin.Context) (int, string) {
+	var a Asset
+	id := c.Param("id")
+	DB.First(&a, id)
+	if a.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Asset %s not found.", id)})
+	}
+
+	tx := DB.Begin()
+	err := tx.Model(&Sensor{}).Where("asset_id = ?", a.ID).Update("asset_id", gorm.Expr("NULL")).Error
+	if err == nil {
+		err = tx.Delete(&a).Error
+	}
+	if err == nil {
+		err = recordAudit(tx, c, "asset", a.ID, &a, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusNoContent, ""
+}
+
+//QueryAssetAnomalies godoc
+//@Summary Query asset anomalies
+//@Description Query the anomalies of all sensors attached to an asset grouped by sensor.
+//@Tags assets
+//@Produce json
+//@Param id path int true "Asset ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param maintenance query string false "Handling of maintenance windows [suppress, tag, ignore]"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} SensorAnomalies
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /assets/{id}/anomalies [get]
+func QueryAssetAnomalies(c *gin.Context) (int, string) {
+	var a Asset
+	id := c.Param("id")
+	DB.Preload("Sensors", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&a, id)
+	if a.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Asset %s not found.", id)})
+	}
+
+	return querySensorsAnomalies(c, a.Sensors)
+}
+
+//saveAsset validates and stores all editable fields of the asset under the passed id, 0 creates a new asset
+func saveAsset(c *gin.Context, a *Asset, id uint, status int) (int, string) {
+	a.ID = id
+	a.Sensors = nil
+
+	if err := a.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if a.MeshID != nil {
+		if status, err := checkMeshID(c, a.RoomModelID, *a.MeshID); err != nil {
+			return status, AsJSON(gin.H{"error": err.Error()})
+		}
+	}
+
+	tx := DB.Begin()
+	var before interface{}
+	if id != 0 {
+		var current Asset
+		tx.First(&current, id)
+		before = &current
+	}
+
+	err := tx.Save(a).Error
+	if err == nil {
+		err = recordAudit(tx, c, "asset", a.ID, before, a)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return status, AsJSON(a)
+}
+
+//findRoomModelOfAsset returns the id of the room model the asset belongs to or 0 if the asset does not exist
+func findRoomModelOfAsset(assetID uint) uint {
+	var a Asset
+	DB.First(&a, assetID)
+	return a.RoomModelID
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+	"time"
+)
+
+//QueryAuditEntries godoc
+//@Summary Query audit trail
+//@Description Query the changes made through the API, newest first unless sort is set. The entries can be filtered
+//@Description by entity (e.g. sensor, model, schedule, maintenance_window, user), entity id, actor and date range.
+//@Description The total number of matching entries is returned in the X-Total-Count header. Requires the admin role.
+//@Tags audit
+//@Produce json
+//@Param entity query string false "Entity type"
+//@Param id query int false "Entity ID"
+//@Param actor query string false "Actor"
+//@Param start_date query string false "Start date"
+//@Param end_date query string false "End date"
+//@Param sort query string false "Columns to sort by"
+//@Param limit query int false "Maximum number of entries (0-1000, 0 for all)"
+//@Param offset query int false "Number of entries to skip"
+//@Success 200 {array} model.AuditEntry
+//@Header 200 {integer} X-Total-Count "Total number of entries"
+//@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /audit [get]
+func QueryAuditEntries(c *gin.Context) (int, string) {
+	list, err := parseListParams(c, &AuditEntry{})
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	queryParams := map[string]interface{}{
+		"id":         int64(0),
+		"start_date": "",
+		"end_date":   "",
+	}
+	if err := fillQueryParams(c, &queryParams); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	q := DB.Model(&AuditEntry{})
+	if entity := c.Query("entity"); entity != "" {
+		q = q.Where("entity = ?", entity)
+	}
+	if queryParams["id"] != int64(0) {
+		q = q.Where("entity_id = ?", queryParams["id"])
+	}
+	if actor := c.Query("actor"); actor != "" {
+		q = q.Where("actor = ?", actor)
+	}
+	if queryParams["start_date"] != "" {
+		q = q.Where("date >= ?", queryParams["start_date"])
+	}
+	if queryParams["end_date"] != "" {
+		q = q.Where("date <= ?", queryParams["end_date"])
+	}
+	if c.Query("sort") == "" {
+		q = q.Order("id desc")
+	}
+
+	r := make([]AuditEntry, 0)
+	if err := list.paginate(c, q, &AuditEntry{}).Find(&r).Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//recordAudit adds an entry to the audit trail for a change of an entity; before is nil for created entities and
+//after for deleted ones. It has to be called with the transaction of the change, which fails if it can not be recorded.
+func recordAudit(tx *gorm.DB, c *gin.Context, entity string, id uint, before interface{}, after interface{}) error {
+	e := AuditEntry{Actor: c.ClientIP(), Date: time.Now().UTC(), Method: c.Request.Method, Path: c.Request.URL.Path,
+		Entity: entity, EntityID: id, Action: AuditUpdate, Before: auditJSON(before), After: auditJSON(after)}
+	if p, ok := c.Get(principalKey); ok {
+		e.Actor = p.(*Principal).Name
+	}
+	if before == nil {
+		e.Action = AuditCreate
+	} else if after == nil {
+		e.Action = AuditDelete
+	}
+
+	return tx.Create(&e).Error
+}
+
+func auditJSON(v interface{}) JSONText {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		fmt.Println("[!]", err)
+		return ""
+	}
+	return JSONText(b)
+}
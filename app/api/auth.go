@@ -0,0 +1,170 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/vi-sense/vi-sense/app/auth"
+	"github.com/vi-sense/vi-sense/app/config"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//AuthMethod is the way a client authenticated itself
+type AuthMethod string
+
+const (
+	APIKeyAuth AuthMethod = "api_key"
+	TokenAuth  AuthMethod = "jwt"
+	CertAuth   AuthMethod = "client_cert"
+)
+
+//Principal is the authenticated client of a request
+type Principal struct {
+	// Name is the name configured for the API key, the subject of the token or the common name of the certificate
+	Name   string      `json:"name"`
+	Method AuthMethod  `json:"method"`
+	Claims auth.Claims `json:"-"`
+}
+
+//principalKey is the key the Principal is stored under in the request context
+const principalKey = "principal"
+
+//authenticator checks the API key or the bearer token of every request
+type authenticator struct {
+	keys     *auth.APIKeys
+	verifier *auth.Verifier
+	// certs defines whether verified client certificates are accepted
+	certs bool
+	// admins are the names of the principals which are admins without being registered as user
+	admins map[string]bool
+}
+
+//newAuthenticator configures the authentication from the API keys and the JSON Web Key Set of the configuration,
+//certs enables client certificates. nil is returned if none of them are configured.
+func newAuthenticator(c config.Auth, certs bool) (*authenticator, error) {
+	keys, err := auth.ParseAPIKeys(c.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	a := authenticator{keys: keys, certs: certs, admins: make(map[string]bool)}
+	for _, name := range c.AdminUsers {
+		a.admins[name] = true
+	}
+	if c.JWTKeySet != "" {
+		ks, err := auth.LoadKeySet(c.JWTKeySet)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key set %s: %s", c.JWTKeySet, err.Error())
+		}
+		a.verifier = &auth.Verifier{Keys: ks, Issuer: c.JWTIssuer, Audience: c.JWTAudience, Leeway: time.Minute}
+	}
+
+	if keys.Len() == 0 && a.verifier == nil && !certs {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+//authenticate is the middleware rejecting every request to a non-public path without valid credentials. API keys
+//are passed in the X-API-Key header, tokens as bearer token in the Authorization header or, for clients which can
+//not set headers like EventSource, in the access_token query parameter. Gateways may present a client certificate
+//instead which has already been verified during the TLS handshake.
+func (a *authenticator) authenticate(c *gin.Context) {
+	if isPublicPath(c.Request.URL.Path) || c.Request.Method == http.MethodOptions {
+		return
+	}
+
+	p, err := a.principal(c)
+	if err != nil {
+		c.Header("WWW-Authenticate", "Bearer realm=\"vi-sense\"")
+		c.String(http.StatusUnauthorized, AsJSON(gin.H{"error": err.Error()}))
+		c.Abort()
+		return
+	}
+	c.Set(principalKey, p)
+}
+
+func (a *authenticator) principal(c *gin.Context) (*Principal, error) {
+	if state := c.Request.TLS; a.certs && state != nil && len(state.VerifiedChains) > 0 {
+		return &Principal{Name: state.VerifiedChains[0][0].Subject.CommonName, Method: CertAuth}, nil
+	}
+
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		name, ok := a.keys.Lookup(key)
+		if !ok {
+			return nil, errors.New("Invalid API key.")
+		}
+		return &Principal{Name: name, Method: APIKeyAuth}, nil
+	}
+
+	token := c.Query("access_token")
+	if h := c.GetHeader("Authorization"); h != "" {
+		if !strings.HasPrefix(h, "Bearer ") {
+			return nil, errors.New("Unsupported authorization scheme.")
+		}
+		token = strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	if token == "" {
+		return nil, errors.New("Authentication required.")
+	}
+	if a.verifier == nil {
+		return nil, errors.New("Bearer tokens are not accepted.")
+	}
+
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid token: %s.", err.Error())
+	}
+	return &Principal{Name: claims.Subject(), Method: TokenAuth, Claims: claims}, nil
+}
+
+//redactedLogFormatter formats the request log like the default logger of gin but hides the value of the access_token
+//query parameter, as tokens in logs could be replayed
+func redactedLogFormatter(p gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if p.IsOutputColor() {
+		statusColor = p.StatusCodeColor()
+		methodColor = p.MethodColor()
+		resetColor = p.ResetColor()
+	}
+
+	if p.Latency > time.Minute {
+		p.Latency = p.Latency - p.Latency%time.Second
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, p.StatusCode, resetColor,
+		p.Latency,
+		p.ClientIP,
+		methodColor, p.Method, resetColor,
+		redactAccessToken(p.Path),
+		p.ErrorMessage,
+	)
+}
+
+//redactAccessToken replaces the value of the access_token query parameter of the path
+func redactAccessToken(path string) string {
+	i := strings.Index(path, "?")
+	if i < 0 {
+		return path
+	}
+
+	query, err := url.ParseQuery(path[i+1:])
+	if err != nil {
+		// a query which can not be parsed may still contain the token
+		return path[:i] + "?[malformed query]"
+	}
+	if _, ok := query["access_token"]; !ok {
+		return path
+	}
+	query.Set("access_token", "REDACTED")
+	return path[:i+1] + query.Encode()
+}
+
+//isPublicPath reports whether the path is accessible without credentials
+func isPublicPath(path string) bool {
+	return path == "/ping" || strings.HasPrefix(path, "/swagger/")
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"math"
+	"net/http"
+	"sort"
+)
+
+//BoundSuggestions contains bounds derived from the historical data of a sensor
+type BoundSuggestions struct {
+	Percentile    float64         `json:"percentile"`
+	DataCount     int             `json:"data_count"`
+	LowerBound    BoundSuggestion `json:"lower_bound"`
+	UpperBound    BoundSuggestion `json:"upper_bound"`
+	GradientBound BoundSuggestion `json:"gradient_bound"`
+	// Anomalies is the number of anomalies all suggested bounds would have produced together
+	Anomalies int `json:"anomalies"`
+}
+
+//BoundSuggestion is a single suggested bound with the number of anomalies it would have produced on its own
+type BoundSuggestion struct {
+	Value     *float64 `json:"value"`
+	Anomalies int      `json:"anomalies"`
+}
+
+//QuerySuggestedBounds godoc
+//@Summary Query suggested bounds
+//@Description Proposes lower, upper and gradient bounds from the distribution of the sensor's historical data.
+//@Description The lower bound is the (100 - percentile)th and the upper bound the percentile-th percentile of all values,
+//@Description the gradient bound is the percentile-th percentile of all absolute gradients.
+//@Description Each suggestion reports how many anomalies it would have produced within the period.
+//@Tags sensors
+//@Produce json
+//@Param id path int true "Sensor ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param percentile query number false "Percentile (50-100]"
+//@Success 200 {object} BoundSuggestions
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id}/suggested-bounds [get]
+func QuerySuggestedBounds(c *gin.Context) (int, string) {
+	id := c.Param("id")
+
+	queryParams := map[string]interface{}{
+		"start_date": "",
+		"end_date":   "",
+		"percentile": 99.0,
+	}
+
+	// check if sensor exists
+	var s Sensor
+	DB.First(&s, id)
+	if s.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor '%s' not found.", id)})
+	}
+
+	err := fillQueryParams(c, &queryParams)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	p := queryParams["percentile"].(float64)
+	if math.IsNaN(p) || math.IsInf(p, 0) || p <= 50 || p > 100 {
+		return http.StatusBadRequest, AsJSON(gin.H{"error":
+		fmt.Sprintf("Percentile is out of its range (50-100] value=%g.", p)})
+	}
+
+	r := findDataInPeriod(&s, queryParams["start_date"].(string), queryParams["end_date"].(string))
+
+	return http.StatusOK, AsJSON(suggestBounds(&s, r, p))
+}
+
+func suggestBounds(s *Sensor, r []Data, p float64) BoundSuggestions {
+	result := BoundSuggestions{Percentile: p, DataCount: len(r)}
+	if len(r) == 0 {
+		return result
+	}
+
+	values := make([]float64, len(r))
+	gradients := make([]float64, len(r))
+	for i := range r {
+		values[i] = r[i].Value
+		gradients[i] = math.Abs(r[i].Gradient)
+	}
+	sort.Float64s(values)
+	sort.Float64s(gradients)
+
+	lower := percentile(values, 100-p)
+	upper := percentile(values, p)
+	gradient := percentile(gradients, p)
+
+	// schedules are left out as the suggestions are meant to replace the sensor's default bounds
+	config := anomalyConfig{windows: findMaintenanceWindows(s), mode: SuppressMaintenance}
+	count := func(lower *float64, upper *float64, gradient *float64) int {
+		candidate := *s
+		candidate.LowerBound, candidate.UpperBound, candidate.GradientBound = lower, upper, gradient
+		return len(findAnomalies(&candidate, r, config))
+	}
+
+	result.LowerBound = BoundSuggestion{Value: &lower, Anomalies: count(&lower, nil, nil)}
+	result.UpperBound = BoundSuggestion{Value: &upper, Anomalies: count(nil, &upper, nil)}
+	result.GradientBound = BoundSuggestion{Value: &gradient, Anomalies: count(nil, nil, &gradient)}
+	result.Anomalies = count(&lower, &upper, &gradient)
+
+	return result
+}
+
+//percentile returns the p-th percentile of the sorted values using linear interpolation between the closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	i := int(math.Floor(rank))
+	if i >= len(sorted)-1 {
+		return round(sorted[len(sorted)-1])
+	}
+	return round(sorted[i] + (rank-float64(i))*(sorted[i+1]-sorted[i]))
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100000) / 100000
+}
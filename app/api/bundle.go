@@ -0,0 +1,588 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	. "github.com/vi-sense/vi-sense/app/storage"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+)
+
+//bundleFileName is the name of the file next to the model.json which contains the records of a model that are not
+//part of the layout read by LoadModels
+const bundleFileName = "bundle.json"
+
+//bundleContent is the content of a bundle.json; sensors are referenced by their position in the model.json, rooms by
+//the position of their floor and their position on it and assets by their position, ids are ignored on import
+type bundleContent struct {
+	Floors             []Floor                   `json:"floors"`
+	Assets             []Asset                   `json:"assets"`
+	Sensors            []bundleSensor            `json:"sensors"`
+	MaintenanceWindows []bundleMaintenanceWindow `json:"maintenance_windows"`
+	Files              []bundleFile              `json:"files"`
+}
+
+//bundleSensor contains the assignments, the bound schedules and the configuration history of a sensor
+type bundleSensor struct {
+	Floor     *int            `json:"floor"`
+	Room      *int            `json:"room"`
+	Asset     *int            `json:"asset"`
+	Schedules []BoundSchedule `json:"schedules"`
+	Configs   []SensorConfig  `json:"configs"`
+}
+
+//bundleMaintenanceWindow is a maintenance window of the model or, if Sensor is set, of a single sensor
+type bundleMaintenanceWindow struct {
+	MaintenanceWindow
+	Sensor *int `json:"sensor"`
+}
+
+//bundleFile is an uploaded file of the model whose content is stored at Path relative to the model.json; Current is
+//set if the url or the image_url of the model references it
+type bundleFile struct {
+	ModelFile
+	Path    string `json:"path"`
+	Current bool   `json:"current"`
+	content []byte
+	ext     string
+}
+
+//ExportRoomModel godoc
+//@Summary Export room model
+//@Description Exports a room model as zip archive in the layout read by LoadModels: sensors/{folder}/model.json
+//@Description contains the model with the configuration of its sensors and sensors/{folder}/s{n}.csv the data of
+//@Description the n-th sensor. sensors/{folder}/bundle.json contains the floors, rooms, assets, bound schedules,
+//@Description maintenance windows, configuration history and uploaded files of the model, the content of the files
+//@Description is stored below sensors/{folder}/files.
+//@Tags models
+//@Produce application/zip
+//@Param id path int true "RoomModel ID"
+//@Success 200 {string} string "zip archive"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/export [get]
+func ExportRoomModel(c *gin.Context) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.Preload("Tags").Preload("Sensors", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Sensors.Tags").First(&q, id)
+	if q.ID == 0 {
+		c.String(http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)}))
+		return
+	}
+
+	b := findBundleContent(&q)
+
+	// urls of uploaded files are restored from the bundle.json on import
+	if isFileUrl(q.Url) {
+		q.Url = ""
+	}
+	if isFileUrl(q.ImageUrl) {
+		q.ImageUrl = ""
+	}
+
+	folder := FolderName(q.Name)
+	if folder == "" {
+		folder = fmt.Sprintf("model-%d", q.ID)
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", folder+".zip"))
+	c.Status(http.StatusOK)
+
+	// the archive is streamed, errors can only be logged once the first bytes were written
+	if err := writeBundle(c.Writer, &q, b, "sensors/"+folder+"/"); err != nil {
+		fmt.Println("[!] export of model", q.ID, "failed:", err)
+	}
+}
+
+//ImportRoomModel godoc
+//@Summary Import room model
+//@Description Imports a zip archive as created by the export as a new room model with its sensors and their data.
+//@Description The archive has to contain exactly one model.json, the csv files and an optional bundle.json with the
+//@Description further records of the model are looked up next to it.
+//@Description Ids are ignored and urls referencing uploaded files of another model are dropped.
+//@Tags models
+//@Accept multipart/form-data
+//@Produce json
+//@Param file formData file true "Zip archive"
+//@Success 201 {object} model.RoomModel
+//@Failure 400 {string} string "bad request"
+//@Failure 413 {string} string "request entity too large"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /import [post]
+func ImportRoomModel(c *gin.Context, limit int64, dataLimit int64) (int, string) {
+	limitBody(c, limit)
+	header, err := c.FormFile("file")
+	if isTooLarge(err) {
+		return http.StatusRequestEntityTooLarge, AsJSON(gin.H{"error":
+		fmt.Sprintf("File exceeds the maximum size of %d bytes.", limit)})
+	} else if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	if header.Size > limit {
+		return http.StatusRequestEntityTooLarge, AsJSON(gin.H{"error":
+		fmt.Sprintf("File exceeds the maximum size of %d bytes.", limit)})
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	defer f.Close()
+
+	archive, err := zip.NewReader(f, header.Size)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": fmt.Sprintf("'%s' is no zip archive.", header.Filename)})
+	}
+
+	m, b, err := readBundle(archive, dataLimit)
+	if err == errBundleTooLarge {
+		return http.StatusRequestEntityTooLarge, AsJSON(gin.H{"error":
+		fmt.Sprintf("The files of the archive exceed the maximum size of %d bytes.", dataLimit)})
+	} else if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	if isFileUrl(m.Url) {
+		m.Url = ""
+	}
+	if isFileUrl(m.ImageUrl) {
+		m.ImageUrl = ""
+	}
+
+	if err := m.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	if err := ValidateTags(m.Tags); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	for i := range m.Sensors {
+		if err := ValidateTags(m.Sensors[i].Tags); err != nil {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+		}
+	}
+	if err := b.validate(len(m.Sensors)); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	var r RoomModel
+	var keys []string
+	tx := DB.Begin()
+	err = tx.Create(m).Error
+	if err == nil {
+		keys, err = createBundleContent(tx, m, b)
+	}
+	if err == nil {
+		tx.Preload("Tags").Preload("Sensors").Preload("Sensors.Tags").First(&r, m.ID)
+		err = recordAudit(tx, c, "model", r.ID, nil, &r)
+	}
+	if err != nil {
+		tx.Rollback()
+		purgeStoredFiles(keys)
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		purgeStoredFiles(keys)
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	for i := range r.Sensors {
+		r.Sensors[i].LatestData = findLatestData(&r.Sensors[i])
+	}
+
+	return http.StatusCreated, AsJSON(&r)
+}
+
+//findBundleContent loads the records of the model which are exported to the bundle.json, the sensors of the model
+//have to be loaded in the order of the model.json
+func findBundleContent(q *RoomModel) *bundleContent {
+	b := &bundleContent{Sensors: make([]bundleSensor, len(q.Sensors))}
+
+	DB.Preload("Rooms", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("room_model_id = ?", q.ID).Order("id").Find(&b.Floors)
+	rooms := make(map[uint][2]int)
+	for i := range b.Floors {
+		for j := range b.Floors[i].Rooms {
+			rooms[b.Floors[i].Rooms[j].ID] = [2]int{i, j}
+		}
+	}
+
+	DB.Where("room_model_id = ?", q.ID).Order("id").Find(&b.Assets)
+	assets := make(map[uint]int, len(b.Assets))
+	for i := range b.Assets {
+		assets[b.Assets[i].ID] = i
+	}
+
+	sensors := make(map[uint]int, len(q.Sensors))
+	for i := range q.Sensors {
+		s, bs := &q.Sensors[i], &b.Sensors[i]
+		sensors[s.ID] = i
+		if s.RoomID != nil {
+			if p, ok := rooms[*s.RoomID]; ok {
+				bs.Floor, bs.Room = &p[0], &p[1]
+			}
+		}
+		if s.AssetID != nil {
+			if p, ok := assets[*s.AssetID]; ok {
+				bs.Asset = &p
+			}
+		}
+		DB.Where("sensor_id = ?", s.ID).Order("id").Find(&bs.Schedules)
+		DB.Where("sensor_id = ?", s.ID).Order("id").Find(&bs.Configs)
+	}
+
+	var windows []MaintenanceWindow
+	DB.Where("room_model_id = ?", q.ID).Or("sensor_id IN ?", DB.Model(&Sensor{}).Select("id").
+		Where("room_model_id = ?", q.ID).SubQuery()).Order("id").Find(&windows)
+	for _, w := range windows {
+		bw := bundleMaintenanceWindow{MaintenanceWindow: w}
+		if w.SensorID != nil {
+			p := sensors[*w.SensorID]
+			bw.Sensor = &p
+		}
+		b.MaintenanceWindows = append(b.MaintenanceWindows, bw)
+	}
+
+	var files []ModelFile
+	DB.Where("room_model_id = ?", q.ID).Order("id").Find(&files)
+	for i, f := range files {
+		url := fileUrl(&f)
+		f.Url = ""
+		b.Files = append(b.Files, bundleFile{ModelFile: f, Path: fmt.Sprintf("files/f%d%s", i+1, path.Ext(f.Key)),
+			Current: url == q.Url || url == q.ImageUrl})
+	}
+	return b
+}
+
+//validate checks the records of the bundle.json of a model.json with the passed number of sensors
+func (b *bundleContent) validate(sensors int) error {
+	for i := range b.Floors {
+		if err := b.Floors[i].Validate(); err != nil {
+			return fmt.Errorf("Floor %d: %w", i, err)
+		}
+		for j := range b.Floors[i].Rooms {
+			if err := b.Floors[i].Rooms[j].Validate(); err != nil {
+				return fmt.Errorf("Room %d of floor %d: %w", j, i, err)
+			}
+		}
+	}
+	for i := range b.Assets {
+		if err := b.Assets[i].Validate(); err != nil {
+			return fmt.Errorf("Asset %d: %w", i, err)
+		}
+	}
+
+	if len(b.Sensors) != 0 && len(b.Sensors) != sensors {
+		return fmt.Errorf("The bundle.json has to describe all %d sensors of the model.json.", sensors)
+	}
+	for i, s := range b.Sensors {
+		if (s.Floor == nil) != (s.Room == nil) || s.Floor != nil && (*s.Floor < 0 || *s.Floor >= len(b.Floors) ||
+			*s.Room < 0 || *s.Room >= len(b.Floors[*s.Floor].Rooms)) {
+			return fmt.Errorf("Sensor %d references an unknown room.", i)
+		}
+		if s.Asset != nil && (*s.Asset < 0 || *s.Asset >= len(b.Assets)) {
+			return fmt.Errorf("Sensor %d references an unknown asset.", i)
+		}
+		for j := range s.Schedules {
+			if err := s.Schedules[j].Validate(); err != nil {
+				return fmt.Errorf("Schedule %d of sensor %d: %w", j, i, err)
+			}
+		}
+	}
+
+	for i := range b.MaintenanceWindows {
+		w := &b.MaintenanceWindows[i]
+		if w.Sensor != nil && (*w.Sensor < 0 || *w.Sensor >= sensors) {
+			return fmt.Errorf("Maintenance window %d references an unknown sensor.", i)
+		}
+		// the references are set once the model was created
+		w.RoomModelID, w.SensorID = new(uint), nil
+		if w.Sensor != nil {
+			w.RoomModelID, w.SensorID = nil, new(uint)
+		}
+		if err := validateMaintenanceWindow(&w.MaintenanceWindow); err != nil {
+			return fmt.Errorf("Maintenance window %d: %w", i, err)
+		}
+	}
+
+	for i := range b.Files {
+		f := &b.Files[i]
+		if f.Kind != ModelFileKind && f.Kind != ImageFileKind {
+			return fmt.Errorf("File %d has the unknown kind '%s'.", i, f.Kind)
+		}
+		contentType, ext, err := detectContentType(f.Kind, f.Name, f.content)
+		if err != nil {
+			return err
+		}
+		f.ContentType, f.ext = contentType, ext
+	}
+	return nil
+}
+
+//createBundleContent creates the records of the bundle.json for the created model and stores the files, it returns
+//the keys of the stored files which have to be purged if the transaction fails
+func createBundleContent(tx *gorm.DB, m *RoomModel, b *bundleContent) ([]string, error) {
+	rooms := make([][]uint, len(b.Floors))
+	for i := range b.Floors {
+		f := b.Floors[i]
+		f.ID, f.RoomModelID, f.Rooms = 0, m.ID, nil
+		if err := tx.Create(&f).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range b.Floors[i].Rooms {
+			r.ID, r.FloorID, r.Sensors = 0, f.ID, nil
+			if err := tx.Create(&r).Error; err != nil {
+				return nil, err
+			}
+			rooms[i] = append(rooms[i], r.ID)
+		}
+	}
+	if err := syncFloorCount(tx, m.ID); err != nil {
+		return nil, err
+	}
+
+	assets := make([]uint, len(b.Assets))
+	for i, a := range b.Assets {
+		a.ID, a.RoomModelID, a.Sensors = 0, m.ID, nil
+		if err := tx.Create(&a).Error; err != nil {
+			return nil, err
+		}
+		assets[i] = a.ID
+	}
+
+	for i, s := range b.Sensors {
+		sensorID := m.Sensors[i].ID
+		assignment := map[string]interface{}{}
+		if s.Room != nil {
+			assignment["room_id"] = rooms[*s.Floor][*s.Room]
+		}
+		if s.Asset != nil {
+			assignment["asset_id"] = assets[*s.Asset]
+		}
+		if len(assignment) > 0 {
+			if err := tx.Model(&Sensor{}).Where("id = ?", sensorID).Updates(assignment).Error; err != nil {
+				return nil, err
+			}
+		}
+		for _, sc := range s.Schedules {
+			sc.ID, sc.SensorID = 0, sensorID
+			if err := tx.Create(&sc).Error; err != nil {
+				return nil, err
+			}
+		}
+		for _, v := range s.Configs {
+			v.ID, v.SensorID = 0, sensorID
+			if err := tx.Create(&v).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, bw := range b.MaintenanceWindows {
+		w := bw.MaintenanceWindow
+		w.ID, w.RoomModelID, w.SensorID = 0, &m.ID, nil
+		if bw.Sensor != nil {
+			w.RoomModelID, w.SensorID = nil, &m.Sensors[*bw.Sensor].ID
+		}
+		if err := tx.Create(&w).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(b.Files))
+	for _, bf := range b.Files {
+		sum := sha256.Sum256(bf.content)
+		f := bf.ModelFile
+		f.ID, f.RoomModelID, f.Size, f.Checksum = 0, m.ID, int64(len(bf.content)), hex.EncodeToString(sum[:])
+		f.Key = fmt.Sprintf("%d/%s%s", m.ID, f.Checksum, bf.ext)
+		if _, err := Files.Save(f.Key, bytes.NewReader(bf.content)); err != nil {
+			return keys, err
+		}
+		keys = append(keys, f.Key)
+		if err := tx.Create(&f).Error; err != nil {
+			return keys, err
+		}
+
+		if bf.Current {
+			field := "url"
+			if f.Kind == ImageFileKind {
+				field = "image_url"
+			}
+			if err := tx.Model(&RoomModel{}).Where("id = ?", m.ID).Update(field, fileUrl(&f)).Error; err != nil {
+				return keys, err
+			}
+		}
+	}
+	return keys, nil
+}
+
+//writeBundle writes the model.json, the data of every sensor, the bundle.json and the uploaded files into the folder
+//of a new zip archive
+func writeBundle(w io.Writer, q *RoomModel, b *bundleContent, folder string) error {
+	z := zip.NewWriter(w)
+	f, err := z.Create(folder + "model.json")
+	if err != nil {
+		return err
+	}
+	if err := WriteModel(f, q); err != nil {
+		return err
+	}
+
+	for i := range q.Sensors {
+		f, err := z.Create(folder + DataFileName(i))
+		if err != nil {
+			return err
+		}
+		if err := WriteData(f, findDataInPeriod(&q.Sensors[i], "", "")); err != nil {
+			return err
+		}
+	}
+
+	f, err = z.Create(folder + bundleFileName)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(b); err != nil {
+		return err
+	}
+
+	for i := range b.Files {
+		if err := copyStoredFile(z, folder+b.Files[i].Path, b.Files[i].Key); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+func copyStoredFile(z *zip.Writer, name string, key string) error {
+	content, err := Files.Open(key)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	f, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, content)
+	return err
+}
+
+//errBundleTooLarge is returned by readBundle if the decompressed files exceed the limit
+var errBundleTooLarge = errors.New("The files of the archive are too large.")
+
+//readBundle reads the model with the data of its sensors from the only model.json of the archive and the records of
+//the bundle.json next to it if there is one, at most limit decompressed bytes are read from all files together
+func readBundle(archive *zip.Reader, limit int64) (*RoomModel, *bundleContent, error) {
+	remaining := &limit
+	files := make(map[string]*zip.File, len(archive.File))
+	var model *zip.File
+	for _, f := range archive.File {
+		files[f.Name] = f
+		if path.Base(f.Name) == "model.json" {
+			if model != nil {
+				return nil, nil, errors.New("The archive contains more than one model.json.")
+			}
+			model = f
+		}
+	}
+	if model == nil {
+		return nil, nil, errors.New("The archive does not contain a model.json.")
+	}
+
+	content, err := readZipFile(model, remaining)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	folder := path.Dir(model.Name)
+	m, err := ReadModel(content, func(name string) (io.ReadCloser, error) {
+		f, ok := files[path.Join(folder, name)]
+		if !ok || name == "" {
+			return nil, fmt.Errorf("The archive does not contain the data file '%s'.", name)
+		}
+		return openZipFile(f, remaining)
+	}, -1)
+	if errors.Is(err, errBundleTooLarge) {
+		return nil, nil, errBundleTooLarge
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	b := &bundleContent{}
+	f, ok := files[path.Join(folder, bundleFileName)]
+	if !ok {
+		return m, b, nil
+	}
+	if content, err = readZipFile(f, remaining); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(content, b); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", bundleFileName, err)
+	}
+	for i := range b.Files {
+		f, ok := files[path.Join(folder, b.Files[i].Path)]
+		if !ok || b.Files[i].Path == "" {
+			return nil, nil, fmt.Errorf("The archive does not contain the file '%s'.", b.Files[i].Path)
+		}
+		if b.Files[i].content, err = readZipFile(f, remaining); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, b, nil
+}
+
+func readZipFile(f *zip.File, remaining *int64) ([]byte, error) {
+	r, err := openZipFile(f, remaining)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+//openZipFile opens the file of the archive, reading fails with errBundleTooLarge once more than the remaining bytes
+//were read
+func openZipFile(f *zip.File, remaining *int64) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > uint64(*remaining) {
+		return nil, errBundleTooLarge
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &boundedReader{ReadCloser: r, remaining: remaining}, nil
+}
+
+//boundedReader counts the bytes read against a limit shared by several readers, as the sizes stored in an archive
+//can not be trusted
+type boundedReader struct {
+	io.ReadCloser
+	remaining *int64
+}
+
+func (r *boundedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	*r.remaining -= int64(n)
+	if *r.remaining < 0 {
+		return n, errBundleTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/vi-sense/vi-sense/app/gltf"
+	. "github.com/vi-sense/vi-sense/app/model"
+	. "github.com/vi-sense/vi-sense/app/storage"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var imageContentTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+//fileLimits contains the maximum upload size in bytes per kind of file
+type fileLimits map[FileKind]int64
+
+//QueryModelFiles godoc
+//@Summary Query model files
+//@Description Query all files which were uploaded for a room model.
+//@Tags models
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Success 200 {array} model.ModelFile
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/files [get]
+func QueryModelFiles(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	r := make([]ModelFile, 0)
+	DB.Where("room_model_id = ?", q.ID).Order("id").Find(&r)
+	for i := range r {
+		r[i].Url = fileUrl(&r[i])
+	}
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//UploadModelFile godoc
+//@Summary Upload model file
+//@Description Uploads a glTF/GLB model (kind=model) or a preview image (kind=image) for a room model.
+//@Description The content is checked against the kind, the url or image_url of the room model is set to the new file.
+//@Tags models
+//@Accept multipart/form-data
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param kind query string true "Kind of file [model, image]"
+//@Param file formData file true "File"
+//@Success 201 {object} model.ModelFile
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 413 {string} string "request entity too large"
+//@Failure 415 {string} string "unsupported media type"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/files [post]
+func UploadModelFile(c *gin.Context, limits fileLimits) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	kind := FileKind(c.Query("kind"))
+	limit, ok := limits[kind]
+	if !ok {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "kind", Value: string(kind)}).Error()})
+	}
+
+	limitBody(c, limit)
+	header, err := c.FormFile("file")
+	if isTooLarge(err) {
+		return http.StatusRequestEntityTooLarge, AsJSON(gin.H{"error":
+		fmt.Sprintf("File exceeds the maximum size of %d bytes.", limit)})
+	} else if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	if header.Size > limit {
+		return http.StatusRequestEntityTooLarge, AsJSON(gin.H{"error":
+		fmt.Sprintf("File exceeds the maximum size of %d bytes.", limit)})
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	defer f.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	if int64(len(content)) > limit {
+		return http.StatusRequestEntityTooLarge, AsJSON(gin.H{"error":
+		fmt.Sprintf("File exceeds the maximum size of %d bytes.", limit)})
+	}
+
+	contentType, ext, err := detectContentType(kind, header.Filename, content)
+	if err != nil {
+		return http.StatusUnsupportedMediaType, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	m := ModelFile{
+		RoomModelID: q.ID,
+		Kind:        kind,
+		Name:        path.Base(header.Filename),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Checksum:    checksum,
+		Key:         fmt.Sprintf("%d/%s%s", q.ID, checksum, ext),
+	}
+
+	if _, err := Files.Save(m.Key, bytes.NewReader(content)); err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tx := DB.Begin()
+	err = tx.Create(&m).Error
+	if err == nil {
+		m.Url = fileUrl(&m)
+		field := "url"
+		if kind == ImageFileKind {
+			field = "image_url"
+		}
+		err = tx.Model(&q).Update(field, m.Url).Error
+	}
+	if err == nil {
+		err = recordAudit(tx, c, "file", m.ID, nil, &m)
+	}
+	if err != nil {
+		tx.Rollback()
+		purgeStoredFiles([]string{m.Key})
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		purgeStoredFiles([]string{m.Key})
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusCreated, AsJSON(&m)
+}
+
+//DownloadModelFile godoc
+//@Summary Download model file
+//@Description Downloads the content of a file which was uploaded for a room model.
+//@Tags models
+//@Produce octet-stream
+//@Param id path int true "RoomModel ID"
+//@Param file_id path int true "ModelFile ID"
+//@Success 200 {string} string "content of the file"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/files/{file_id} [get]
+func DownloadModelFile(c *gin.Context) {
+	m, status, msg := findModelFile(c)
+	if m == nil {
+		c.String(status, msg)
+		return
+	}
+
+	f, err := Files.Open(m.Key)
+	if err != nil {
+		c.String(http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()}))
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Type", m.ContentType)
+	c.Header("ETag", fmt.Sprintf("\"%s\"", m.Checksum))
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", m.Name))
+	http.ServeContent(c.Writer, c.Request, m.Name, m.CreatedAt, f)
+}
+
+//DeleteModelFile godoc
+//@Summary Delete model file
+//@Description Deletes an uploaded file, the url or image_url of the room model is cleared if it referenced the file.
+//@Tags models
+//@Param id path int true "RoomModel ID"
+//@Param file_id path int true "ModelFile ID"
+//@Success 204 {string} string "no content"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/files/{file_id} [delete]
+func DeleteModelFile(c *gin.Context) (int, string) {
+	m, status, msg := findModelFile(c)
+	if m == nil {
+		return status, msg
+	}
+
+	tx := DB.Begin()
+	keys, err := deleteModelFiles(tx, []ModelFile{*m})
+	if err == nil {
+		err = recordAudit(tx, c, "file", m.ID, m, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+	purgeStoredFiles(keys)
+
+	return http.StatusNoContent, ""
+}
+
+func findModelFile(c *gin.Context) (*ModelFile, int, string) {
+	var m ModelFile
+	id := c.Param("file_id")
+	DB.Where("room_model_id = ?", c.Param("id")).First(&m, id)
+	if m.ID == 0 {
+		return nil, http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("File %s not found.", id)})
+	}
+	return &m, 0, ""
+}
+
+//deleteModelFiles removes the passed files from the database and clears the urls referencing them; the storage keys
+//of the files are returned to be passed to purgeStoredFiles once the transaction has been committed
+func deleteModelFiles(tx *gorm.DB, files []ModelFile) ([]string, error) {
+	keys := make([]string, 0, len(files))
+	for i := range files {
+		f := &files[i]
+		url := fileUrl(f)
+		if err := tx.Model(&RoomModel{}).Where("id = ? AND url = ?", f.RoomModelID, url).
+			Update("url", "").Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&RoomModel{}).Where("id = ? AND image_url = ?", f.RoomModelID, url).
+			Update("image_url", "").Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(f).Error; err != nil {
+			return nil, err
+		}
+		keys = append(keys, f.Key)
+	}
+	return keys, nil
+}
+
+//purgeStoredFiles deletes the content of the keys from the storage unless another record with the same checksum still
+//references it; failures only leave unreferenced content behind and are therefore just logged
+func purgeStoredFiles(keys []string) {
+	for _, key := range keys {
+		var count int
+		DB.Model(&ModelFile{}).Where("storage_key = ?", key).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := Files.Delete(key); err != nil {
+			fmt.Println("[!] failed to delete stored file", key, err)
+		}
+	}
+}
+
+//multipartOverhead is the room left for the headers and boundaries of a multipart upload besides the file itself
+const multipartOverhead = 1 << 20
+
+//limitBody bounds the request body to the limit and the multipart overhead, so oversized uploads are rejected while
+//they are read instead of after they have been buffered
+func limitBody(c *gin.Context, limit int64) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+}
+
+//isTooLarge reports whether the error was caused by exceeding the limit of limitBody
+func isTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "request body too large")
+}
+
+//detectContentType checks the content against the kind of file and returns its content type and file extension
+func detectContentType(kind FileKind, name string, content []byte) (string, string, error) {
+	switch kind {
+	case ModelFileKind:
+		if _, err := gltf.Parse(content); err != nil {
+			return "", "", fmt.Errorf("'%s' is neither a glTF nor a GLB file: %s.", name, err.Error())
+		}
+		if gltf.IsBinary(content) {
+			return "model/gltf-binary", ".glb", nil
+		}
+		return "model/gltf+json", ".gltf", nil
+
+	case ImageFileKind:
+		contentType := http.DetectContentType(content)
+		if ext, ok := imageContentTypes[contentType]; ok {
+			return contentType, ext, nil
+		}
+		return "", "", fmt.Errorf("Unsupported image type '%s'.", contentType)
+	}
+
+	return "", "", fmt.Errorf("Unknown kind of file '%s'.", kind)
+}
+
+//fileUrlPattern matches the urls returned by fileUrl
+var fileUrlPattern = regexp.MustCompile(`^/models/\d+/files/\d+$`)
+
+//isFileUrl reports whether the url references an uploaded file
+func isFileUrl(url string) bool {
+	return fileUrlPattern.MatchString(url)
+}
+
+func fileUrl(f *ModelFile) string {
+	return fmt.Sprintf("/models/%d/files/%d", f.RoomModelID, f.ID)
+}
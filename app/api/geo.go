@@ -0,0 +1,155 @@
+package api
+
+import (
+	"github.com/jinzhu/gorm"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//earthRadius is the mean radius of the earth in kilometers
+const earthRadius = 6371.0088
+
+//geoFilter restricts room models to a circle around a point and/or a bounding box
+type geoFilter struct {
+	near   *point
+	radius float64
+	box    *box
+}
+
+type point struct {
+	lat float64
+	lon float64
+}
+
+//box is a bounding box, a min longitude greater than the max longitude spans the antimeridian
+type box struct {
+	minLat float64
+	minLon float64
+	maxLat float64
+	maxLon float64
+}
+
+//parseGeoFilter parses the near, radius_km and bbox query parameters, nil is returned if none of them is set
+func parseGeoFilter(near string, radius string, bbox string) (*geoFilter, error) {
+	if near == "" && radius == "" && bbox == "" {
+		return nil, nil
+	}
+
+	f := geoFilter{}
+	if near != "" {
+		v, err := parseCoordinates(near, 2)
+		if err != nil || !validLatitude(v[0]) || !validLongitude(v[1]) {
+			return nil, &ParamParseError{Param: "near", Value: near}
+		}
+		f.near = &point{lat: v[0], lon: v[1]}
+	}
+
+	if radius != "" {
+		v, err := strconv.ParseFloat(radius, 64)
+		if err != nil || v <= 0 || f.near == nil {
+			return nil, &ParamParseError{Param: "radius_km", Value: radius}
+		}
+		f.radius = v
+	}
+
+	if bbox != "" {
+		v, err := parseCoordinates(bbox, 4)
+		if err != nil || !validLatitude(v[0]) || !validLongitude(v[1]) || !validLatitude(v[2]) ||
+			!validLongitude(v[3]) || v[0] > v[2] {
+			return nil, &ParamParseError{Param: "bbox", Value: bbox}
+		}
+		f.box = &box{minLat: v[0], minLon: v[1], maxLat: v[2], maxLon: v[3]}
+	}
+
+	return &f, nil
+}
+
+//apply adds conditions to the query which preselect all models within the bounding box and the box around the circle;
+//they only use comparisons to work on every database, the exact distance has to be checked by contains
+func (f *geoFilter) apply(q *gorm.DB) *gorm.DB {
+	if f.box != nil {
+		q = f.box.apply(q)
+	}
+	if f.near != nil && f.radius > 0 {
+		q = f.near.boundingBox(f.radius).apply(q)
+	}
+	return q
+}
+
+//contains checks whether the coordinates lie within the radius around the point, it is always true without a radius
+func (f *geoFilter) contains(distance float64) bool {
+	return f.radius == 0 || distance <= f.radius
+}
+
+func (b *box) apply(q *gorm.DB) *gorm.DB {
+	q = q.Where("latitude BETWEEN ? AND ?", b.minLat, b.maxLat)
+	if b.minLon <= b.maxLon {
+		return q.Where("longitude BETWEEN ? AND ?", b.minLon, b.maxLon)
+	}
+	return q.Where("(longitude >= ? OR longitude <= ?)", b.minLon, b.maxLon)
+}
+
+//boundingBox returns the smallest box containing the circle with the radius in km around the point
+func (p *point) boundingBox(radius float64) *box {
+	delta := radius / earthRadius * 180 / math.Pi
+	b := box{minLat: p.lat - delta, maxLat: p.lat + delta, minLon: -180, maxLon: 180}
+
+	// the circle contains a pole, all longitudes are covered
+	if b.minLat <= -90 || b.maxLat >= 90 {
+		b.minLat, b.maxLat = math.Max(b.minLat, -90), math.Min(b.maxLat, 90)
+		return &b
+	}
+
+	lonDelta := math.Asin(math.Sin(radius/earthRadius)/math.Cos(p.lat*math.Pi/180)) * 180 / math.Pi
+	if lonDelta >= 180 || math.IsNaN(lonDelta) {
+		return &b
+	}
+	b.minLon, b.maxLon = wrapLongitude(p.lon-lonDelta), wrapLongitude(p.lon+lonDelta)
+	return &b
+}
+
+//distance returns the great-circle distance in km between the points using the haversine formula
+func (p *point) distance(lat float64, lon float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat - p.lat) * rad
+	dLon := (lon - p.lon) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(p.lat*rad)*math.Cos(lat*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func parseCoordinates(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, &ParamParseError{Value: s}
+	}
+
+	v := make([]float64, n)
+	for i := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+func wrapLongitude(lon float64) float64 {
+	if lon < -180 {
+		return lon + 360
+	}
+	if lon > 180 {
+		return lon - 360
+	}
+	return lon
+}
+
+func validLatitude(lat float64) bool {
+	return lat >= -90 && lat <= 90
+}
+
+func validLongitude(lon float64) bool {
+	return lon >= -180 && lon <= 180
+}
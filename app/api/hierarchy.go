@@ -0,0 +1,644 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+)
+
+//Aggregate contains statistics over the data of all sensors with the same measurement unit
+type Aggregate struct {
+	MeasurementUnit string  `json:"measurement_unit"`
+	Sensors         int     `json:"sensors"`
+	Count           int     `json:"count"`
+	Min             float64 `json:"min"`
+	Max             float64 `json:"max"`
+	Mean            float64 `json:"mean"`
+}
+
+//QueryFloors godoc
+//@Summary Query floors
+//@Description Query all floors of a room model ordered by level, each with its rooms and zones.
+//@Tags hierarchy
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Success 200 {array} model.Floor
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/floors [get]
+func QueryFloors(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	r := make([]Floor, 0)
+	DB.Preload("Rooms").Where("room_model_id = ?", q.ID).Order("level").Find(&r)
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//CreateFloor godoc
+//@Summary Create floor
+//@Description Creates a new floor inside a room model, the floors of the room model are set to the number of its floors.
+//@Tags hierarchy
+//@Accept json
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param floor body model.Floor true "Floor"
+//@Success 201 {object} model.Floor
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/floors [post]
+func CreateFloor(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	var f Floor
+	if err := c.ShouldBindJSON(&f); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	f.ID = 0
+	f.RoomModelID = q.ID
+	f.Rooms = nil
+
+	if err := f.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tx := DB.Begin()
+	err := tx.Create(&f).Error
+	if err == nil {
+		err = syncFloorCount(tx, q.ID)
+	}
+	if err == nil {
+		f.Rooms = make([]Room, 0)
+		err = recordAudit(tx, c, "floor", f.ID, nil, &f)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusCreated, AsJSON(&f)
+}
+
+//QueryFloor godoc
+//@Summary Query floor
+//@Description Query a single floor with its rooms and their sensors.
+//@Tags hierarchy
+//@Produce json
+//@Param id path int true "Floor ID"
+//@Success 200 {object} model.Floor
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /floors/{id} [get]
+func QueryFloor(c *gin.Context) (int, string) {
+	var f Floor
+	id := c.Param("id")
+	DB.Preload("Rooms").Preload("Rooms.Sensors").First(&f, id)
+	if f.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Floor %s not found.", id)})
+	}
+
+	for i := range f.Rooms {
+		for j := range f.Rooms[i].Sensors {
+			f.Rooms[i].Sensors[j].LatestData = findLatestData(&f.Rooms[i].Sensors[j])
+		}
+	}
+
+	return http.StatusOK, AsJSON(&f)
+}
+
+//UpdateFloor godoc
+//@Summary Replace floor
+//@Description Replaces the name and level of a floor, its rooms remain untouched.
+//@Tags hierarchy
+//@Accept json
+//@Produce json
+//@Param id path int true "Floor ID"
+//@Param floor body model.Floor true "Floor"
+//@Success 200 {object} model.Floor
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /floors/{id} [put]
+func UpdateFloor(c *gin.Context) (int, string) {
+	var f Floor
+	id := c.Param("id")
+	DB.First(&f, id)
+	if f.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Floor %s not found.", id)})
+	}
+
+	var u Floor
+	if err := c.ShouldBindJSON(&u); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	u.ID = f.ID
+	u.RoomModelID = f.RoomModelID
+	u.Rooms = nil
+
+	if err := u.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tx := DB.Begin()
+	var before Floor
+	tx.First(&before, f.ID)
+	err := tx.Save(&u).Error
+	if err == nil {
+		err = recordAudit(tx, c, "floor", u.ID, &before, &u)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	DB.Preload("Rooms").First(&u, u.ID)
+
+	return http.StatusOK, AsJSON(&u)
+}
+
+//DeleteFloor godoc
+//@Summary Delete floor
+//@Description Deletes a floor together with its rooms, the sensors of the rooms are unassigned but kept.
+//@Description The floors of the room model are set to the number of its remaining floors unless none remain.
+//@Tags hierarchy
+//@Param id path int true "Floor ID"
+//@Success 204 {string} string "no content"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /floors/{id} [delete]
+func DeleteFloor(c *gin.Context) (int, string) {
+	var f Floor
+	id := c.Param("id")
+	DB.Preload("Rooms").First(&f, id)
+	if f.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Floor %s not found.", id)})
+	}
+
+	tx := DB.Begin()
+	err := deleteFloors(tx, []uint{f.ID})
+	if err == nil {
+		err = syncFloorCount(tx, f.RoomModelID)
+	}
+	if err == nil {
+		err = recordAudit(tx, c, "floor", f.ID, &f, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusNoContent, ""
+}
+
+//CreateRoom godoc
+//@Summary Create room
+//@Description Creates a new room or zone on a floor.
+//@Tags hierarchy
+//@Accept json
+//@Produce json
+//@Param id path int true "Floor ID"
+//@Param room body model.Room true "Room"
+//@Success 201 {object} model.Room
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /floors/{id}/rooms [post]
+func CreateRoom(c *gin.Context) (int, string) {
+	var f Floor
+	id := c.Param("id")
+	DB.First(&f, id)
+	if f.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Floor %s not found.", id)})
+	}
+
+	var r Room
+	if err := c.ShouldBindJSON(&r); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	r.ID = 0
+	r.FloorID = f.ID
+	r.Sensors = nil
+
+	if err := r.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tx := DB.Begin()
+	err := tx.Create(&r).Error
+	if err == nil {
+		err = recordAudit(tx, c, "room", r.ID, nil, &r)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusCreated, AsJSON(&r)
+}
+
+//QueryRoom godoc
+//@Summary Query room
+//@Description Query a single room or zone with its sensors.
+//@Tags hierarchy
+//@Produce json
+//@Param id path int true "Room ID"
+//@Success 200 {object} model.Room
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /rooms/{id} [get]
+func QueryRoom(c *gin.Context) (int, string) {
+	var r Room
+	id := c.Param("id")
+	DB.Preload("Sensors").First(&r, id)
+	if r.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Room %s not found.", id)})
+	}
+
+	for i := range r.Sensors {
+		r.Sensors[i].LatestData = findLatestData(&r.Sensors[i])
+	}
+
+	return http.StatusOK, AsJSON(&r)
+}
+
+//UpdateRoom godoc
+//@Summary Replace room
+//@Description Replaces the name and kind of a room or zone, its sensors remain untouched.
+//@Description A floor_id moves the room to another floor of the same room model, 0 keeps the current floor.
+//@Tags hierarchy
+//@Accept json
+//@Produce json
+//@Param id path int true "Room ID"
+//@Param room body model.Room true "Room"
+//@Success 200 {object} model.Room
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /rooms/{id} [put]
+func UpdateRoom(c *gin.Context) (int, string) {
+	var r Room
+	id := c.Param("id")
+	DB.First(&r, id)
+	if r.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Room %s not found.", id)})
+	}
+
+	var u Room
+	if err := c.ShouldBindJSON(&u); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	u.ID = r.ID
+	u.Sensors = nil
+	if u.FloorID == 0 {
+		u.FloorID = r.FloorID
+	} else if u.FloorID != r.FloorID && findRoomModelOfFloor(u.FloorID) != findRoomModelOfFloor(r.FloorID) {
+		return http.StatusBadRequest, AsJSON(gin.H{"error":
+		fmt.Sprintf("Floor %d is not part of the room model of the room.", u.FloorID)})
+	}
+
+	if err := u.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tx := DB.Begin()
+	var before Room
+	tx.First(&before, r.ID)
+	err := tx.Save(&u).Error
+	if err == nil {
+		err = recordAudit(tx, c, "room", u.ID, &before, &u)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusOK, AsJSON(&u)
+}
+
+//DeleteRoom godoc
+//@Summary Delete room
+//@Description Deletes a room or zone, its sensors are unassigned but kept.
+//@Tags hierarchy
+//@Param id path int true "Room ID"
+//@Success 204 {string} string "no content"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /rooms/{id} [delete]
+func DeleteRoom(c *gin.Context) (int, string) {
+	var r Room
+	id := c.Param("id")
+	DB.First(&r, id)
+	if r.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Room %s not found.", id)})
+	}
+
+	tx := DB.Begin()
+	err := deleteRooms(tx, []uint{r.ID})
+	if err == nil {
+		err = recordAudit(tx, c, "room", r.ID, &r, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusNoContent, ""
+}
+
+//QueryFloorAnomalies godoc
+//@Summary Query floor anomalies
+//@Description Query the anomalies of all sensors in the rooms of a floor grouped by sensor.
+//@Tags hierarchy
+//@Produce json
+//@Param id path int true "Floor ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param maintenance query string false "Handling of maintenance windows [suppress, tag, ignore]"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} SensorAnomalies
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /floors/{id}/anomalies [get]
+func QueryFloorAnomalies(c *gin.Context) (int, string) {
+	sensors, status, msg := findFloorSensors(c)
+	if sensors == nil {
+		return status, msg
+	}
+	return querySensorsAnomalies(c, sensors)
+}
+
+//QueryRoomAnomalies godoc
+//@Summary Query room anomalies
+//@Description Query the anomalies of all sensors of a room or zone grouped by sensor.
+//@Tags hierarchy
+//@Produce json
+//@Param id path int true "Room ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param maintenance query string false "Handling of maintenance windows [suppress, tag, ignore]"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} SensorAnomalies
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /rooms/{id}/anomalies [get]
+func QueryRoomAnomalies(c *gin.Context) (int, string) {
+	sensors, status, msg := findRoomSensors(c)
+	if sensors == nil {
+		return status, msg
+	}
+	return querySensorsAnomalies(c, sensors)
+}
+
+//QueryFloorAggregates godoc
+//@Summary Query floor aggregates
+//@Description Query minimum, maximum and mean of the data of all sensors on a floor grouped by measurement unit.
+//@Tags hierarchy
+//@Produce json
+//@Param id path int true "Floor ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} Aggregate
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /floors/{id}/aggregates [get]
+func QueryFloorAggregates(c *gin.Context) (int, string) {
+	sensors, status, msg := findFloorSensors(c)
+	if sensors == nil {
+		return status, msg
+	}
+	return querySensorsAggregates(c, sensors)
+}
+
+//QueryRoomAggregates godoc
+//@Summary Query room aggregates
+//@Description Query minimum, maximum and mean of the data of all sensors of a room or zone grouped by measurement unit.
+//@Tags hierarchy
+//@Produce json
+//@Param id path int true "Room ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} Aggregate
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /rooms/{id}/aggregates [get]
+func QueryRoomAggregates(c *gin.Context) (int, string) {
+	sensors, status, msg := findRoomSensors(c)
+	if sensors == nil {
+		return status, msg
+	}
+	return querySensorsAggregates(c, sensors)
+}
+
+func querySensorsAggregates(c *gin.Context, sensors []Sensor) (int, string) {
+	queryParams := map[string]interface{}{
+		"start_date": "",
+		"end_date":   "",
+	}
+
+	err := fillQueryParams(c, &queryParams)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	sensors, err = filterSensorsByTags(c, sensors)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	r := make([]Aggregate, 0)
+	if len(sensors) == 0 {
+		return http.StatusOK, AsJSON(r)
+	}
+
+	ids := make([]uint, len(sensors))
+	for i := range sensors {
+		ids[i] = sensors[i].ID
+	}
+
+	data := DB.NewScope(&Data{}).TableName()
+	q := DB.Table(data).
+		Select("sensors.measurement_unit, COUNT(DISTINCT sensors.id) AS sensors, COUNT(*) AS count, " +
+			"MIN(value) AS min, MAX(value) AS max, AVG(value) AS mean").
+		Joins(fmt.Sprintf("JOIN sensors ON sensors.id = %s.sensor_id", data)).
+		Where("sensors.id IN (?)", ids)
+	if queryParams["start_date"] != "" {
+		q = q.Where("date >= ?", queryParams["start_date"])
+	}
+	if queryParams["end_date"] != "" {
+		q = q.Where("date <= ?", queryParams["end_date"])
+	}
+
+	if err := q.Group("sensors.measurement_unit").Order("sensors.measurement_unit").Scan(&r).Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	for i := range r {
+		r[i].Mean = round(r[i].Mean)
+	}
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//findFloorSensors returns the sensors of all rooms of the floor with the id passed as path parameter,
+//nil is returned together with the response if the floor does not exist
+func findFloorSensors(c *gin.Context) ([]Sensor, int, string) {
+	var f Floor
+	id := c.Param("id")
+	DB.Preload("Rooms").First(&f, id)
+	if f.ID == 0 {
+		return nil, http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Floor %s not found.", id)})
+	}
+
+	ids := make([]uint, len(f.Rooms))
+	for i := range f.Rooms {
+		ids[i] = f.Rooms[i].ID
+	}
+
+	sensors := make([]Sensor, 0)
+	if len(ids) > 0 {
+		DB.Where("room_id IN (?)", ids).Order("id").Find(&sensors)
+	}
+	return sensors, 0, ""
+}
+
+//findRoomSensors returns the sensors of the room with the id passed as path parameter,
+//nil is returned together with the response if the room does not exist
+func findRoomSensors(c *gin.Context) ([]Sensor, int, string) {
+	var r Room
+	id := c.Param("id")
+	DB.First(&r, id)
+	if r.ID == 0 {
+		return nil, http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Room %s not found.", id)})
+	}
+
+	sensors := make([]Sensor, 0)
+	DB.Where("room_id = ?", r.ID).Order("id").Find(&sensors)
+	return sensors, 0, ""
+}
+
+//findRoomModelOfRoom returns the id of the room model the room belongs to or 0 if the room does not exist
+func findRoomModelOfRoom(roomID uint) uint {
+	var r Room
+	DB.First(&r, roomID)
+	if r.ID == 0 {
+		return 0
+	}
+
+	var f Floor
+	DB.First(&f, r.FloorID)
+	return f.RoomModelID
+}
+
+//syncFloorCount sets the floors of the room model to the number of its floors, the floors are kept if it has none
+func syncFloorCount(tx *gorm.DB, modelID uint) error {
+	var count int
+	if err := tx.Model(&Floor{}).Where("room_model_id = ?", modelID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	return tx.Model(&RoomModel{}).Where("id = ?", modelID).Update("floors", count).Error
+}
+
+//deleteFloors deletes the floors with the passed ids together with their rooms
+func deleteFloors(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var rooms []Room
+	tx.Where("floor_id IN (?)", ids).Find(&rooms)
+	roomIDs := make([]uint, len(rooms))
+	for i := range rooms {
+		roomIDs[i] = rooms[i].ID
+	}
+
+	if err := deleteRooms(tx, roomIDs); err != nil {
+		return err
+	}
+
+	return tx.Where("id IN (?)", ids).Delete(&Floor{}).Error
+}
+
+//deleteRooms deletes the rooms with the passed ids and unassigns their sensors
+func deleteRooms(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&Sensor{}).Where("room_id IN (?)", ids).Update("room_id", gorm.Expr("NULL")).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("id IN (?)", ids).Delete(&Room{}).Error
+}
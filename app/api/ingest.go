@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//NewData is a single reading sent to the ingestion
+type NewData struct {
+	Value *float64   `json:"value" example:"58.85"`
+	Date  *time.Time `json:"date" example:"2019-10-01T00:00:00Z"`
+}
+
+//IngestAudit is the summary of ingested readings recorded in the audit log
+type IngestAudit struct {
+	SensorID  uint      `json:"sensor_id"`
+	Count     int       `json:"count"`
+	FirstDate time.Time `json:"first_date"`
+	LastDate  time.Time `json:"last_date"`
+}
+
+//ingestMu serializes the ingestion so readings are stored and published in chronological order
+var ingestMu sync.Mutex
+
+//IngestSensorData godoc
+//@Summary Ingest sensor data
+//@Description Stores new readings of a sensor and publishes them together with the anomalies they start or end to
+//@Description the live subscribers. The readings have to be in chronological order and newer than the sensor's
+//@Description latest reading by at least a second each, their gradients are calculated from the preceding reading.
+//@Tags sensors
+//@Accept json
+//@Produce json
+//@Param id path int true "Sensor ID"
+//@Param data body []NewData true "Readings"
+//@Success 201 {array} model.Data
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id}/data [post]
+func IngestSensorData(c *gin.Context) (int, string) {
+	var s Sensor
+	id := c.Param("id")
+	DB.First(&s, id)
+	if s.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %s not found.", id)})
+	}
+
+	var in []NewData
+	if err := c.ShouldBindJSON(&in); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	if len(in) == 0 {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": "At least one reading is required."})
+	}
+
+	ingestMu.Lock()
+	defer ingestMu.Unlock()
+
+	latest := findLatestData(&s)
+	previous := &latest
+	data := make([]Data, len(in))
+	for i, d := range in {
+		if d.Value == nil || d.Date == nil {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": fmt.Sprintf("Reading %d requires a value and a date.", i)})
+		}
+
+		data[i] = Data{SensorID: s.ID, Value: *d.Value, Date: Date{Time: d.Date.UTC()}}
+		if previous.ID != 0 || i > 0 {
+			if data[i].Date.Before(previous.Date.Add(time.Second)) {
+				return http.StatusBadRequest, AsJSON(gin.H{"error":
+				fmt.Sprintf("Reading %d is not at least a second newer than the preceding reading.", i)})
+			}
+			data[i].Gradient = Gradient(previous, &data[i])
+		}
+		previous = &data[i]
+	}
+
+	tx := DB.Begin()
+	for i := range data {
+		if err := tx.Create(&data[i]).Error; err != nil {
+			tx.Rollback()
+			return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+		}
+	}
+	summary := IngestAudit{SensorID: s.ID, Count: len(data), FirstDate: data[0].Date.Time,
+		LastDate: data[len(data)-1].Date.Time}
+	if err := recordAudit(tx, c, "sensor_data", s.ID, nil, &summary); err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	Live.publish(&s, data)
+
+	return http.StatusCreated, AsJSON(data)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//maxLimit is the maximum number of entries a single page of a list endpoint can contain
+const maxLimit = 1000
+
+//listParams contains the order and the page requested from a list endpoint
+type listParams struct {
+	// order contains the columns to sort by, each optionally followed by desc
+	order  []string
+	limit  int
+	offset int
+}
+
+//parseListParams parses the sort, limit and offset query parameters. sort is a comma separated list of columns
+//of the passed model, each prefixed with - to sort descending. The id is always added as last column to get
+//a stable order across pages.
+func parseListParams(c *gin.Context, value interface{}) (*listParams, error) {
+	p := listParams{}
+
+	fields := sortableFields(value)
+	for _, s := range strings.Split(c.Query("sort"), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		direction := ""
+		if strings.HasPrefix(s, "-") {
+			s, direction = s[1:], " desc"
+		}
+		if !fields[s] {
+			return nil, &ParamParseError{Param: "sort", Value: c.Query("sort")}
+		}
+		p.order = append(p.order, s+direction)
+	}
+	p.order = append(p.order, "id")
+
+	limit, err := parseIntParam(c.Query("limit"), 0)
+	if err != nil || limit < 0 || limit > maxLimit {
+		return nil, &ParamParseError{Param: "limit", Value: c.Query("limit")}
+	}
+	p.limit = int(limit)
+
+	offset, err := parseIntParam(c.Query("offset"), 0)
+	if err != nil || offset < 0 {
+		return nil, &ParamParseError{Param: "offset", Value: c.Query("offset")}
+	}
+	p.offset = int(offset)
+
+	return &p, nil
+}
+
+//sorted adds the order to the query
+func (p *listParams) sorted(q *gorm.DB) *gorm.DB {
+	for _, o := range p.order {
+		q = q.Order(o)
+	}
+	return q
+}
+
+//paginate adds the order and the page to the query and sets the total number of entries as header
+func (p *listParams) paginate(c *gin.Context, q *gorm.DB, value interface{}) *gorm.DB {
+	var total int
+	q.Model(value).Count(&total)
+	c.Header("X-Total-Count", strconv.Itoa(total))
+
+	q = p.sorted(q)
+	if p.limit > 0 {
+		q = q.Limit(p.limit)
+	} else if p.offset > 0 {
+		// sqlite does not accept an offset without a limit
+		q = q.Limit(math.MaxInt64)
+	}
+	if p.offset > 0 {
+		q = q.Offset(p.offset)
+	}
+	return q
+}
+
+//window sets the total number of entries as header and returns the bounds of the page for a slice of n entries,
+//it is used if the entries are filtered after they were loaded
+func (p *listParams) window(c *gin.Context, n int) (int, int) {
+	c.Header("X-Total-Count", strconv.Itoa(n))
+
+	start := p.offset
+	if start > n {
+		start = n
+	}
+	end := n
+	if p.limit > 0 && start+p.limit < n {
+		end = start + p.limit
+	}
+	return start, end
+}
+
+//sortableFields returns the columns of all fields of the model which are stored in the database
+func sortableFields(value interface{}) map[string]bool {
+	fields := make(map[string]bool)
+	for _, f := range DB.NewScope(value).Fields() {
+		if !f.IsIgnored && f.IsNormal {
+			fields[f.DBName] = true
+		}
+	}
+	return fields
+}
+
+//containsPattern returns a LIKE pattern matching every value which contains s, wildcards in s are escaped
+func containsPattern(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+	return fmt.Sprintf("%%%s%%", strings.ToLower(r.Replace(s)))
+}
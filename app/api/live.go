@@ -0,0 +1,278 @@
+package api
+
+import (
+	. "github.com/vi-sense/vi-sense/app/model"
+	"sync"
+	"time"
+)
+
+//EventType is the kind of a live Event
+type EventType string
+
+const (
+	//DataEvent is sent for every new reading of a sensor
+	DataEvent EventType = "data"
+	//AnomalyStartEvent is sent when a reading starts an anomaly
+	AnomalyStartEvent EventType = "anomaly_start"
+	//AnomalyEndEvent is sent when a reading ends an anomaly, its end data is the last reading violating the bound
+	AnomalyEndEvent EventType = "anomaly_end"
+	//StatusEvent contains the latest reading of a sensor and the anomaly going on at it, if any
+	StatusEvent EventType = "status"
+	//HeartbeatEvent is sent periodically to keep idle connections open and detect dead ones
+	HeartbeatEvent EventType = "heartbeat"
+	//ErrorEvent is sent if a client message is invalid or before the connection of a too slow client is closed
+	ErrorEvent EventType = "error"
+)
+
+//Event is a single update sent to live subscribers
+type Event struct {
+	// ID increases with every data and anomaly event, status events carry the id of the latest event at the time
+	// they were created, heartbeats and errors have no id
+	ID          uint64    `json:"id,omitempty"`
+	Type        EventType `json:"type"`
+	RoomModelID uint      `json:"room_model_id,omitempty"`
+	SensorID    uint      `json:"sensor_id,omitempty"`
+	Data        *Data     `json:"data,omitempty"`
+	Anomaly     *Anomaly  `json:"anomaly,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	// ReplayID is set if the event was sent by a replay of stored data
+	ReplayID uint `json:"replay_id,omitempty"`
+}
+
+const (
+	//liveBuffer is the number of events queued for a subscriber before it is dropped as too slow
+	liveBuffer = 256
+	//liveHistory is the number of past events kept to let reconnecting subscribers catch up
+	liveHistory = 1024
+)
+
+//Live distributes the ingested readings and the anomalies they start or end to all subscribers
+var Live = newLiveHub()
+
+type liveHub struct {
+	mu          sync.Mutex
+	seq         uint64
+	subscribers map[*subscription]bool
+	// history is a ring buffer of the latest events, once it is full head is the index of the oldest one
+	history []Event
+	head    int
+	// detectors keep the anomaly state of every sensor which received readings since they were last invalidated
+	detectors map[uint]*anomalyDetector
+	// generation increases with every invalidation, so detectors primed meanwhile are not kept
+	generation uint64
+}
+
+//subscription receives the events of all sensors it is subscribed to directly or by their room model
+type subscription struct {
+	// events is closed once the subscription is cancelled or dropped
+	events  chan Event
+	models  map[uint]bool
+	sensors map[uint]bool
+	// dropped is set if the subscriber did not keep up and missed events
+	dropped bool
+}
+
+func newLiveHub() *liveHub {
+	return &liveHub{subscribers: make(map[*subscription]bool), detectors: make(map[uint]*anomalyDetector)}
+}
+
+//subscribe registers a new subscriber for the room models and sensors with the passed ids
+func (h *liveHub) subscribe(models []uint, sensors []uint) *subscription {
+	s := newSubscription()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subscribers[s] = true
+	s.add(models, sensors)
+	return s
+}
+
+//subscribeAfter registers a new subscriber like subscribe and returns the past events after the one with the passed id
+//which it would have received as well as the id of the latest event. ok is false if some of the past events are no
+//longer known, e.g. after a restart.
+func (h *liveHub) subscribeAfter(models []uint, sensors []uint, id uint64) (s *subscription, missed []Event,
+	last uint64, ok bool) {
+	s = newSubscription()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subscribers[s] = true
+	s.add(models, sensors)
+
+	n := len(h.history)
+	if id > h.seq || (n == 0 && id < h.seq) || (n > 0 && h.history[h.head].ID > id+1) {
+		return s, nil, h.seq, false
+	}
+	for i := 0; i < n; i++ {
+		if e := h.history[(h.head+i)%n]; e.ID > id && s.matches(&e) {
+			missed = append(missed, e)
+		}
+	}
+	return s, missed, h.seq, true
+}
+
+//update adds or removes room models and sensors of a subscription
+func (h *liveHub) update(s *subscription, models []uint, sensors []uint, remove bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if remove {
+		s.remove(models, sensors)
+	} else {
+		s.add(models, sensors)
+	}
+}
+
+//unsubscribe cancels the subscription, it is a no-op if the subscription was already dropped
+func (h *liveHub) unsubscribe(s *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[s] {
+		delete(h.subscribers, s)
+		close(s.events)
+	}
+}
+
+//publish evaluates the new readings of the sensor, which have to be stored already, and sends them together with
+//the anomalies they start or end to all subscribers of the sensor or its room model
+func (h *liveHub) publish(s *Sensor, data []Data) {
+	if len(data) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.emit(h.detector(s, data[0].Date.Time), s, data, 0)
+}
+
+//replay sends stored readings of the sensor like publish, the anomalies are evaluated by the passed detector of the
+//replay instead of the one of the live data
+func (h *liveHub) replay(replayID uint, d *anomalyDetector, s *Sensor, data []Data) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.emit(d, s, data, replayID)
+}
+
+func (h *liveHub) emit(d *anomalyDetector, s *Sensor, data []Data, replayID uint) {
+	for i := range data {
+		h.send(Event{Type: DataEvent, RoomModelID: s.RoomModelID, SensorID: s.ID, Data: &data[i], ReplayID: replayID})
+
+		started, ended := d.step(&data[i])
+		for j := range ended {
+			h.send(Event{Type: AnomalyEndEvent, RoomModelID: s.RoomModelID, SensorID: s.ID, Anomaly: &ended[j],
+				ReplayID: replayID})
+		}
+		for j := range started {
+			h.send(Event{Type: AnomalyStartEvent, RoomModelID: s.RoomModelID, SensorID: s.ID, Anomaly: &started[j],
+				ReplayID: replayID})
+		}
+	}
+	d.forget()
+}
+
+//invalidate discards the anomaly state of the sensors with the passed ids or of all sensors if none are passed;
+//it has to be called whenever bounds, schedules or maintenance windows change
+func (h *liveHub) invalidate(sensorIDs ...uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.generation++
+	if len(sensorIDs) == 0 {
+		h.detectors = make(map[uint]*anomalyDetector)
+	}
+	for _, id := range sensorIDs {
+		delete(h.detectors, id)
+	}
+}
+
+//detector returns the anomaly detector of the sensor, a new one is primed with the stored readings before the date;
+//it has to be called with h.mu held, which is released while the readings are loaded
+func (h *liveHub) detector(s *Sensor, before time.Time) *anomalyDetector {
+	d := h.detectors[s.ID]
+	for d == nil {
+		generation := h.generation
+		h.mu.Unlock()
+		primed := primeAnomalyDetector(s, before)
+		h.mu.Lock()
+
+		// another ingest may have primed the sensor meanwhile and an invalidation makes the primed state outdated
+		if d = h.detectors[s.ID]; d == nil && generation == h.generation {
+			d = primed
+			h.detectors[s.ID] = d
+		}
+	}
+	return d
+}
+
+//primeAnomalyDetector returns a new detector for a copy of the sensor which already evaluated the stored readings
+//within statusLookback before the date
+func primeAnomalyDetector(s *Sensor, before time.Time) *anomalyDetector {
+	sensor := *s
+	d := newAnomalyDetector(&sensor, loadAnomalyConfig(&sensor, SuppressMaintenance))
+
+	r := make([]Data, 0)
+	DB.Where("sensor_id = ? AND date >= ? AND date < ?", s.ID, before.Add(-statusLookback), before).
+		Order("date").Find(&r)
+	for i := range r {
+		d.push(&r[i])
+	}
+	d.forget()
+	return d
+}
+
+//send queues the event for every matching subscriber, subscribers with a full queue are dropped instead of blocking
+//the ingestion
+func (h *liveHub) send(e Event) {
+	h.seq++
+	e.ID = h.seq
+
+	if len(h.history) < liveHistory {
+		h.history = append(h.history, e)
+	} else {
+		h.history[h.head] = e
+		h.head = (h.head + 1) % liveHistory
+	}
+
+	for s := range h.subscribers {
+		if !s.matches(&e) {
+			continue
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			s.dropped = true
+			delete(h.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+func newSubscription() *subscription {
+	return &subscription{events: make(chan Event, liveBuffer), models: make(map[uint]bool), sensors: make(map[uint]bool)}
+}
+
+func (s *subscription) matches(e *Event) bool {
+	return s.models[e.RoomModelID] || s.sensors[e.SensorID]
+}
+
+func (s *subscription) add(models []uint, sensors []uint) {
+	for _, id := range models {
+		s.models[id] = true
+	}
+	for _, id := range sensors {
+		s.sensors[id] = true
+	}
+}
+
+func (s *subscription) remove(models []uint, sensors []uint) {
+	for _, id := range models {
+		delete(s.models, id)
+	}
+	for _, id := range sensors {
+		delete(s.sensors, id)
+	}
+}
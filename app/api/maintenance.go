@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+	"sort"
+	"time"
+)
+
+//QueryMaintenanceWindows godoc
+//@Summary Query maintenance windows
+//@Description Query all maintenance windows, optionally filtered by room model or sensor.
+//@Tags maintenance
+//@Produce json
+//@Param room_model_id query int false "RoomModel ID"
+//@Param sensor_id query int false "Sensor ID"
+//@Success 200 {array} model.MaintenanceWindow
+//@Failure 400 {string} string "bad request"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /maintenance [get]
+func QueryMaintenanceWindows(c *gin.Context) (int, string) {
+	q, err := maintenanceWindowQuery(c)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	r := make([]MaintenanceWindow, 0)
+	q.Order("start_date").Find(&r)
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//QueryUpcomingMaintenance godoc
+//@Summary Query upcoming maintenance
+//@Description Query the next occurrence of all maintenance windows which are going on or start within the passed
+//@Description number of hours (default 24), optionally filtered by room model or sensor, ordered by start date.
+//@Tags maintenance
+//@Produce json
+//@Param room_model_id query int false "RoomModel ID"
+//@Param sensor_id query int false "Sensor ID"
+//@Param hours query int false "Hours from now the occurrences have to start within"
+//@Success 200 {array} model.MaintenanceOccurrence
+//@Failure 400 {string} string "bad request"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /maintenance/upcoming [get]
+func QueryUpcomingMaintenance(c *gin.Context) (int, string) {
+	hours, err := parseIntParam(c.Query("hours"), 24)
+	if err != nil || hours <= 0 {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "hours", Value: c.Query("hours")}).Error()})
+	}
+
+	q, err := maintenanceWindowQuery(c)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	now := time.Now().UTC()
+	horizon := now.Add(time.Duration(hours) * time.Hour)
+	var windows []MaintenanceWindow
+	q.Where("start_date < ?", horizon).Find(&windows)
+
+	r := make([]MaintenanceOccurrence, 0)
+	for i := range windows {
+		if o, ok := windows[i].Next(now); ok && o.StartDate.Before(horizon) {
+			r = append(r, o)
+		}
+	}
+	sort.SliceStable(r, func(i, j int) bool { return r[i].StartDate.Before(r[j].StartDate) })
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//maintenanceWindowQuery returns a query of the maintenance windows the client may access filtered by the
+//room_model_id and sensor_id parameters
+func maintenanceWindowQuery(c *gin.Context) (*gorm.DB, error) {
+	queryParams := map[string]interface{}{
+		"room_model_id": int64(0),
+		"sensor_id":     int64(0),
+	}
+
+	if err := fillQueryParams(c, &queryParams); err != nil {
+		return nil, err
+	}
+
+	q := DB
+	if acc := accessOf(c); !acc.all() {
+		sensors := acc.scope(DB.Model(&Sensor{}).Select("id"), "room_model_id").SubQuery()
+		q = q.Where("room_model_id IN (?) OR sensor_id IN ?", acc.modelIDs(), sensors)
+	}
+	if queryParams["room_model_id"] != int64(0) {
+		q = q.Where("room_model_id = ?", queryParams["room_model_id"])
+	}
+	if queryParams["sensor_id"] != int64(0) {
+		q = q.Where("sensor_id = ?", queryParams["sensor_id"])
+	}
+	return q, nil
+}
+
+//CreateMaintenanceWindow godoc
+//@Summary Create maintenance window
+//@Description Creates a one-off or recurring maintenance window for either a room model or a single sensor.
+//@Description Anomalies within the window are suppressed or tagged by the anomaly endpoints.
+//@Tags maintenance
+//@Accept json
+//@Produce json
+//@Param maintenance_window body model.MaintenanceWindow true "MaintenanceWindow"
+//@Success 201 {object} model.MaintenanceWindow
+//@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /maintenance [post]
+func CreateMaintenanceWindow(c *gin.Context) (int, string) {
+	var w MaintenanceWindow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	w.ID = 0
+
+	if err := validateMaintenanceWindow(&w); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	var modelID uint
+	if w.RoomModelID != nil {
+		var m RoomModel
+		DB.First(&m, *w.RoomModelID)
+		if m.ID == 0 {
+			return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %d not found.", *w.RoomModelID)})
+		}
+		modelID = m.ID
+	} else {
+		var s Sensor
+		DB.First(&s, *w.SensorID)
+		if s.ID == 0 {
+			return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %d not found.", *w.SensorID)})
+		}
+		modelID = s.RoomModelID
+	}
+	if !accessOf(c).permits(modelID) {
+		return http.StatusForbidden, AsJSON(gin.H{"error": fmt.Sprintf("Model %d is not granted.", modelID)})
+	}
+
+	tx := DB.Begin()
+	err := tx.Create(&w).Error
+	if err == nil {
+		err = recordAudit(tx, c, "maintenance_window", w.ID, nil, &w)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+	invalidateMaintenanceWindow(&w)
+
+	return http.StatusCreated, AsJSON(&w)
+}
+
+//DeleteMaintenanceWindow godoc
+//@Summary Delete maintenance window
+//@Description Deletes a single maintenance window by id.
+//@Tags maintenance
+//@Param id path int true "MaintenanceWindow ID"
+//@Success 204 {string} string "no content"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /maintenance/{id} [delete]
+func DeleteMaintenanceWindow(c *gin.Context) (int, string) {
+	var w MaintenanceWindow
+	id := c.Param("id")
+	DB.First(&w, id)
+	if w.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Maintenance window %s not found.", id)})
+	}
+
+	tx := DB.Begin()
+	err := tx.Delete(&w).Error
+	if err == nil {
+		err = recordAudit(tx, c, "maintenance_window", w.ID, &w, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+	invalidateMaintenanceWindow(&w)
+
+	return http.StatusNoContent, ""
+}
+
+func validateMaintenanceWindow(w *MaintenanceWindow) error {
+	if (w.RoomModelID == nil) == (w.SensorID == nil) {
+		return errors.New("Exactly one of 'room_model_id' and 'sensor_id' has to be set.")
+	}
+
+	if !w.EndDate.After(w.StartDate) {
+		return errors.New("'end_date' has to be after 'start_date'.")
+	}
+
+	switch w.Recurrence {
+	case Once:
+		w.Until = nil
+	case Daily, Weekly:
+		if w.EndDate.Sub(w.StartDate) >= w.Period() {
+			return fmt.Errorf("A %s maintenance window has to be shorter than its recurrence period.", w.Recurrence)
+		}
+		if w.Until != nil && w.Until.Before(w.StartDate) {
+			return errors.New("'until' has to be after 'start_date'.")
+		}
+	default:
+		return &ParamParseError{Param: "recurrence", Value: string(w.Recurrence)}
+	}
+
+	return nil
+}
+
+//invalidateMaintenanceWindow discards the live anomaly state of all sensors the window applies to
+func invalidateMaintenanceWindow(w *MaintenanceWindow) {
+	if w.SensorID != nil {
+		Live.invalidate(*w.SensorID)
+		return
+	}
+
+	var ids []uint
+	DB.Model(&Sensor{}).Where("room_model_id = ?", *w.RoomModelID).Pluck("id", &ids)
+	if len(ids) > 0 {
+		Live.invalidate(ids...)
+	}
+}
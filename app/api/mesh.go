@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/vi-sense/vi-sense/app/gltf"
+	. "github.com/vi-sense/vi-sense/app/model"
+	. "github.com/vi-sense/vi-sense/app/storage"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+//errNoModelFile is returned if the url of a room model does not reference an uploaded glTF/GLB file
+var errNoModelFile = errors.New("no uploaded glTF/GLB file")
+
+//meshCache keeps the meshes of parsed model files by checksum
+var meshCache = struct {
+	sync.Mutex
+	meshes map[string][]gltf.Mesh
+}{meshes: make(map[string][]gltf.Mesh)}
+
+//QueryModelMeshes godoc
+//@Summary Query model meshes
+//@Description Query all nodes of the room model's uploaded glTF/GLB file which reference a mesh.
+//@Description The id of a node is the value expected as mesh_id of a sensor.
+//@Tags models
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Success 200 {array} gltf.Mesh
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/meshes [get]
+func QueryModelMeshes(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	meshes, err := findModelMeshes(&q)
+	if err == errNoModelFile {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s has no uploaded glTF/GLB file.", id)})
+	} else if err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusOK, AsJSON(meshes)
+}
+
+//findModelMeshes parses the glTF/GLB file referenced by the url of the room model
+func findModelMeshes(q *RoomModel) ([]gltf.Mesh, error) {
+	var f ModelFile
+	var files []ModelFile
+	DB.Where("room_model_id = ? AND kind = ?", q.ID, ModelFileKind).Find(&files)
+	for i := range files {
+		if fileUrl(&files[i]) == q.Url {
+			f = files[i]
+		}
+	}
+	if f.ID == 0 {
+		return nil, errNoModelFile
+	}
+
+	meshCache.Lock()
+	meshes, ok := meshCache.meshes[f.Checksum]
+	meshCache.Unlock()
+	if ok {
+		return meshes, nil
+	}
+
+	r, err := Files.Open(f.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := gltf.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	meshes = d.MeshNodes()
+	meshCache.Lock()
+	meshCache.meshes[f.Checksum] = meshes
+	meshCache.Unlock()
+
+	return meshes, nil
+}
+
+//meshExists checks whether the mesh id exists in the glTF/GLB file of the room model; models without an uploaded
+//file can not be checked and accept every mesh id
+func meshExists(modelID uint, meshID int64) (bool, error) {
+	var q RoomModel
+	DB.First(&q, modelID)
+
+	meshes, err := findModelMeshes(&q)
+	if err == errNoModelFile {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for _, m := range meshes {
+		if m.ID == meshID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//checkMeshID rejects mesh ids which do not exist in the room model unless the query parameter force is set,
+//in which case a warning header is added to the response
+func checkMeshID(c *gin.Context, modelID uint, meshID int64) (int, error) {
+	exists, err := meshExists(modelID, meshID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if exists {
+		return 0, nil
+	}
+
+	msg := fmt.Sprintf("Mesh %d does not exist in model %d.", meshID, modelID)
+	force, err := parseBoolParam(c.Query("force"), false)
+	if err != nil {
+		return http.StatusBadRequest, &ParamParseError{Param: "force", Value: c.Query("force")}
+	}
+	if !force {
+		return http.StatusBadRequest, errors.New(msg)
+	}
+
+	c.Header("Warning", fmt.Sprintf("199 - %q", msg))
+	return 0, nil
+}
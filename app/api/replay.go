@@ -0,0 +1,394 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+//ReplayState is the state of a Replay
+type ReplayState string
+
+const (
+	ReplayRunning  ReplayState = "running"
+	ReplayPaused   ReplayState = "paused"
+	ReplayStopped  ReplayState = "stopped"
+	ReplayFinished ReplayState = "finished"
+)
+
+//replayBatch is the number of readings a replay loads at once
+const replayBatch = 1000
+
+//finished and stopped replays are kept for the replayRetention, at most the replayLimit latest of them
+const (
+	replayRetention = time.Hour
+	replayLimit     = 100
+)
+
+//Replay plays back the stored data of a room model through the live updates as if it was ingested right now
+type Replay struct {
+	ID          uint      `json:"id"`
+	RoomModelID uint      `json:"room_model_id"`
+	Start       time.Time `json:"start" example:"2019-10-01T00:00:00Z"`
+	// Speed is the factor the time between two readings is shortened by, 1 replays in real time
+	Speed float64     `json:"speed" example:"60"`
+	State ReplayState `json:"state"`
+	// Position is the date of the latest replayed reading
+	Position *time.Time `json:"position"`
+	Count    int        `json:"count"`
+	ended    time.Time
+}
+
+//NewReplay contains the parameters of a new replay
+type NewReplay struct {
+	RoomModelID uint       `json:"room_model_id" example:"1"`
+	Start       *time.Time `json:"start" example:"2019-10-01T00:00:00Z"`
+	// Speed defaults to 1
+	Speed float64 `json:"speed" example:"60"`
+}
+
+//replays contains the replays which are running, paused or not yet pruned, they are not persisted
+var replays = struct {
+	sync.Mutex
+	seq     uint
+	entries map[uint]*Replay
+	// wakes interrupt the wait of the runners for the next reading after the state changed
+	wakes map[uint]chan struct{}
+}{entries: make(map[uint]*Replay), wakes: make(map[uint]chan struct{})}
+
+//QueryReplays godoc
+//@Summary Query replays
+//@Description Query the running and paused replays and the finished or stopped replays of the last hour, at most
+//@Description the latest 100 of them.
+//@Tags replays
+//@Produce json
+//@Success 200 {array} Replay
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /replays [get]
+func QueryReplays(c *gin.Context) (int, string) {
+	replays.Lock()
+	defer replays.Unlock()
+
+	acc := accessOf(c)
+	r := make([]Replay, 0, len(replays.entries))
+	for _, e := range replays.entries {
+		if acc.permits(e.RoomModelID) {
+			r = append(r, *e)
+		}
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//QueryReplay godoc
+//@Summary Query replay
+//@Description Query the state and the position of a single replay.
+//@Tags replays
+//@Produce json
+//@Param id path int true "Replay ID"
+//@Success 200 {object} Replay
+//@Failure 404 {string} string "not found"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /replays/{id} [get]
+func QueryReplay(c *gin.Context) (int, string) {
+	replays.Lock()
+	defer replays.Unlock()
+
+	r, status, err := findReplay(c.Param("id"))
+	if err != nil {
+		return status, AsJSON(gin.H{"error": err.Error()})
+	}
+	return http.StatusOK, AsJSON(*r)
+}
+
+//StartReplay godoc
+//@Summary Start replay
+//@Description Starts to replay the stored data of all sensors of a room model from the start date on. Every reading is
+//@Description sent to the live updates (WebSocket and Server-Sent Events) as data event together with the anomaly
+//@Description events it causes, each marked with the id of the replay. The time between two readings is the time
+//@Description between their dates divided by the speed. The anomalies are evaluated separately from the live data
+//@Description based on all readings before the start date; changes of bounds are not applied to running replays.
+//@Tags replays
+//@Accept json
+//@Produce json
+//@Param replay body NewReplay true "NewReplay"
+//@Success 201 {object} Replay
+//@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
+//@Failure 404 {string} string "not found"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /replays [post]
+func StartReplay(c *gin.Context) (int, string) {
+	var n NewReplay
+	if err := c.ShouldBindJSON(&n); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	if n.Start == nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": "'start' must not be empty."})
+	}
+	if n.Speed == 0 {
+		n.Speed = 1
+	}
+	if n.Speed < 0 {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": fmt.Sprintf("'speed' has to be positive value=%g.", n.Speed)})
+	}
+
+	var q RoomModel
+	DB.Preload("Sensors").First(&q, n.RoomModelID)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %d not found.", n.RoomModelID)})
+	}
+	if !accessOf(c).permits(q.ID) {
+		return http.StatusForbidden, AsJSON(gin.H{"error": fmt.Sprintf("Model %d is not granted.", q.ID)})
+	}
+
+	sensors := make(map[uint]*Sensor, len(q.Sensors))
+	detectors := make(map[uint]*anomalyDetector, len(q.Sensors))
+	for i := range q.Sensors {
+		sensors[q.Sensors[i].ID] = &q.Sensors[i]
+		detectors[q.Sensors[i].ID] = primeAnomalyDetector(&q.Sensors[i], *n.Start)
+	}
+
+	replays.Lock()
+	defer replays.Unlock()
+	pruneReplays()
+
+	r := &Replay{ID: replays.seq + 1, RoomModelID: q.ID, Start: n.Start.UTC(), Speed: n.Speed, State: ReplayRunning}
+	if err := recordAudit(DB, c, "replay", r.ID, nil, r); err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	replays.seq++
+	wake := make(chan struct{}, 1)
+	replays.entries[r.ID] = r
+	replays.wakes[r.ID] = wake
+	go r.run(sensors, detectors, wake)
+
+	return http.StatusCreated, AsJSON(*r)
+}
+
+//PauseReplay godoc
+//@Summary Pause replay
+//@Description Pauses a running replay, resuming it continues with the remaining time until the next reading.
+//@Tags replays
+//@Produce json
+//@Param id path int true "Replay ID"
+//@Success 200 {object} Replay
+//@Failure 404 {string} string "not found"
+//@Failure 409 {string} string "conflict"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /replays/{id}/pause [post]
+func PauseReplay(c *gin.Context) (int, string) {
+	return changeReplayState(c, ReplayPaused, ReplayRunning)
+}
+
+//ResumeReplay godoc
+//@Summary Resume replay
+//@Description Resumes a paused replay.
+//@Tags replays
+//@Produce json
+//@Param id path int true "Replay ID"
+//@Success 200 {object} Replay
+//@Failure 404 {string} string "not found"
+//@Failure 409 {string} string "conflict"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /replays/{id}/resume [post]
+func ResumeReplay(c *gin.Context) (int, string) {
+	return changeReplayState(c, ReplayRunning, ReplayPaused)
+}
+
+//StopReplay godoc
+//@Summary Stop replay
+//@Description Stops a running or paused replay for good.
+//@Tags replays
+//@Produce json
+//@Param id path int true "Replay ID"
+//@Success 200 {object} Replay
+//@Failure 404 {string} string "not found"
+//@Failure 409 {string} string "conflict"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /replays/{id}/stop [post]
+func StopReplay(c *gin.Context) (int, string) {
+	return changeReplayState(c, ReplayStopped, ReplayRunning, ReplayPaused)
+}
+
+//changeReplayState sets the state of the replay if it is in one of the passed states and wakes up its runner
+func changeReplayState(c *gin.Context, state ReplayState, from ...ReplayState) (int, string) {
+	replays.Lock()
+	defer replays.Unlock()
+
+	r, status, err := findReplay(c.Param("id"))
+	if err != nil {
+		return status, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	allowed := false
+	for _, s := range from {
+		allowed = allowed || r.State == s
+	}
+	if !allowed {
+		return http.StatusConflict, AsJSON(gin.H{"error": fmt.Sprintf("Replay %d is %s.", r.ID, r.State)})
+	}
+
+	after := *r
+	after.State = state
+	if err := recordAudit(DB, c, "replay", r.ID, r, &after); err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	r.State = state
+	select {
+	case replays.wakes[r.ID] <- struct{}{}:
+	default:
+	}
+	if state == ReplayStopped {
+		r.end(state)
+	}
+
+	return http.StatusOK, AsJSON(*r)
+}
+
+//findReplay returns the replay with the id, the caller has to hold the lock of the replays
+func findReplay(id string) (*Replay, int, error) {
+	n, err := parseIntParam(id, 0)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Replay %s not found.", id)
+	}
+	r, ok := replays.entries[uint(n)]
+	if !ok {
+		return nil, http.StatusNotFound, fmt.Errorf("Replay %s not found.", id)
+	}
+	return r, http.StatusOK, nil
+}
+
+//run replays the readings of the sensors batch by batch in chronological order until all are replayed or it is stopped
+func (r *Replay) run(sensors map[uint]*Sensor, detectors map[uint]*anomalyDetector, wake <-chan struct{}) {
+	ids := make([]uint, 0, len(sensors))
+	for id := range sensors {
+		ids = append(ids, id)
+	}
+
+	var previous *Data
+	for {
+		batch := make([]Data, 0)
+		q := DB.Where("sensor_id IN (?)", ids)
+		if previous == nil {
+			q = q.Where("date >= ?", r.Start)
+		} else {
+			// readings with the same date are ordered by their id
+			q = q.Where("date > ? OR (date = ? AND id > ?)", previous.Date.Time, previous.Date.Time, previous.ID)
+		}
+		q.Order("date").Order("id").Limit(replayBatch).Find(&batch)
+
+		if len(batch) == 0 {
+			r.finish()
+			return
+		}
+
+		for i := range batch {
+			var delay time.Duration
+			if previous != nil {
+				delay = time.Duration(float64(batch[i].Date.Sub(previous.Date.Time)) / r.Speed)
+			}
+			if !r.wait(delay, wake) {
+				return
+			}
+
+			Live.replay(r.ID, detectors[batch[i].SensorID], sensors[batch[i].SensorID], batch[i:i+1])
+			r.advance(&batch[i])
+			previous = &batch[i]
+		}
+	}
+}
+
+//wait waits for the delay while the replay is running, false is returned if the replay got stopped
+func (r *Replay) wait(delay time.Duration, wake <-chan struct{}) bool {
+	for {
+		replays.Lock()
+		state := r.State
+		replays.Unlock()
+
+		switch state {
+		case ReplayStopped:
+			return false
+		case ReplayPaused:
+			<-wake
+			continue
+		}
+
+		if delay <= 0 {
+			return true
+		}
+
+		start := time.Now()
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			return true
+		case <-wake:
+			timer.Stop()
+			delay -= time.Since(start)
+		}
+	}
+}
+
+func (r *Replay) advance(d *Data) {
+	replays.Lock()
+	defer replays.Unlock()
+
+	position := d.Date.Time
+	r.Position = &position
+	r.Count++
+}
+
+func (r *Replay) finish() {
+	replays.Lock()
+	defer replays.Unlock()
+
+	if r.State == ReplayRunning || r.State == ReplayPaused {
+		r.end(ReplayFinished)
+	}
+}
+
+//end sets the final state of the replay, releases its wake channel and prunes the replays which ended before, the
+//caller has to hold the lock of the replays
+func (r *Replay) end(state ReplayState) {
+	r.State = state
+	r.ended = time.Now()
+	delete(replays.wakes, r.ID)
+	pruneReplays()
+}
+
+//pruneReplays removes the replays which ended more than the replayRetention ago and the oldest ended replays beyond
+//the replayLimit, the caller has to hold the lock of the replays
+func pruneReplays() {
+	ended := make([]*Replay, 0)
+	for id, e := range replays.entries {
+		if e.ended.IsZero() {
+			continue
+		}
+		if time.Since(e.ended) > replayRetention {
+			delete(replays.entries, id)
+			continue
+		}
+		ended = append(ended, e)
+	}
+
+	if len(ended) > replayLimit {
+		sort.Slice(ended, func(i, j int) bool { return ended[i].ended.Before(ended[j].ended) })
+		for _, e := range ended[:len(ended)-replayLimit] {
+			delete(replays.entries, e.ID)
+		}
+	}
+}
@@ -3,23 +3,134 @@ package api
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 	. "github.com/vi-sense/vi-sense/app/model"
 	"net/http"
+	"sort"
 )
 
 //QueryRoomModels godoc
 //@Summary Query models
-//@Description Query all available room models
+//@Description Query all available room models.
+//@Description The models can be filtered by type, by city (part of the address, case-insensitive) and by tags.
+//@Description Each tag parameter is either key:value or only a key to match every value, all of them have to match.
+//@Description They can be restricted to a radius around a point (near, radius_km) and/or a bounding box (bbox).
+//@Description If near is set every model contains its distance to the point and the models are ordered by distance
+//@Description unless sort is set.
+//@Description sort accepts a comma separated list of columns (e.g. name,-floors), each prefixed with - to sort descending.
+//@Description The total number of models matching the filters is returned in the X-Total-Count header.
 //@Tags models
 //@Produce  json
+//@Param type query string false "Type of the models"
+//@Param city query string false "City contained in the address"
+//@Param tag query []string false "Tags the models must have as key:value or key" collectionFormat(multi)
+//@Param near query string false "Point to search around as latitude,longitude"
+//@Param radius_km query number false "Radius around near in km"
+//@Param bbox query string false "Bounding box as min_latitude,min_longitude,max_latitude,max_longitude"
+//@Param sensors query bool false "Include the sensors of the models (default true)"
+//@Param sort query string false "Columns to sort by"
+//@Param limit query int false "Maximum number of models (0-1000, 0 for all)"
+//@Param offset query int false "Number of models to skip"
 //@Success 200 {array} model.RoomModel
+//@Header 200 {integer} X-Total-Count "Total number of models"
 //@Failure 400 {string} string "bad request"
 //@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
 //@Router /models [get]
-func QueryRoomModels() (int, string) {
-	var q []RoomModel
-	DB.Preload("Sensors").Find(&q)
-	return http.StatusOK, AsJSON(&q)
+func QueryRoomModels(c *gin.Context) (int, string) {
+	geo, err := parseGeoFilter(c.Query("near"), c.Query("radius_km"), c.Query("bbox"))
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	list, err := parseListParams(c, &RoomModel{})
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	withSensors, err := parseBoolParam(c.Query("sensors"), true)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error":
+		(&ParamParseError{Param: "sensors", Value: c.Query("sensors")}).Error()})
+	}
+
+	tags, err := parseTagFilter(c)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	q := accessOf(c).scope(tags.apply(DB.Model(&RoomModel{}).Preload("Tags"), &RoomModel{}), "id")
+	if t := c.Query("type"); t != "" {
+		q = q.Where("type = ?", t)
+	}
+	if city := c.Query("city"); city != "" {
+		q = q.Where("LOWER(address) LIKE ? ESCAPE '\\'", containsPattern(city))
+	}
+	if geo != nil {
+		q = geo.apply(q)
+	}
+
+	r := make([]RoomModel, 0)
+	if geo != nil && geo.near != nil {
+		// the exact distance is calculated after loading, so the page is selected afterwards
+		if err := list.sorted(q).Find(&r).Error; err != nil {
+			return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+		}
+		r = filterByDistance(r, geo, c.Query("sort") == "")
+		start, end := list.window(c, len(r))
+		r = r[start:end]
+	} else if err := list.paginate(c, q, &RoomModel{}).Find(&r).Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if withSensors {
+		loadSensors(r)
+	}
+
+	return http.StatusOK, AsJSON(&r)
+}
+
+//filterByDistance sets the distance of the models to the searched point, removes those outside the radius
+//and optionally orders them by distance
+func filterByDistance(models []RoomModel, geo *geoFilter, byDistance bool) []RoomModel {
+	r := make([]RoomModel, 0, len(models))
+	for i := range models {
+		d := round(geo.near.distance(models[i].Location.Latitude, models[i].Location.Longitude))
+		if geo.contains(d) {
+			models[i].Distance = &d
+			r = append(r, models[i])
+		}
+	}
+
+	if byDistance {
+		sort.SliceStable(r, func(i, j int) bool {
+			return *r[i].Distance < *r[j].Distance
+		})
+	}
+	return r
+}
+
+//loadSensors loads the sensors of all passed models with a single query
+func loadSensors(models []RoomModel) {
+	if len(models) == 0 {
+		return
+	}
+
+	ids := make([]uint, len(models))
+	index := make(map[uint]int, len(models))
+	for i := range models {
+		ids[i] = models[i].ID
+		index[models[i].ID] = i
+		models[i].Sensors = make([]Sensor, 0)
+	}
+
+	var sensors []Sensor
+	DB.Preload("Tags").Where("room_model_id IN (?)", ids).Order("id").Find(&sensors)
+	for _, s := range sensors {
+		i := index[s.RoomModelID]
+		models[i].Sensors = append(models[i].Sensors, s)
+	}
 }
 
 //QueryRoomModel godoc
@@ -32,11 +143,13 @@ func QueryRoomModels() (int, string) {
 //@Failure 400 {string} string "bad request"
 //@Failure 404 {string} string "not found"
 //@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
 //@Router /models/{id} [get]
 func QueryRoomModel(c *gin.Context) (int, string) {
 	var q RoomModel
 	id := c.Param("id")
-	DB.Preload("Sensors").First(&q, id)
+	DB.Preload("Tags").Preload("Sensors").Preload("Sensors.Tags").First(&q, id)
 	if q.ID == 0 {
 		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
 	}
@@ -47,3 +160,434 @@ func QueryRoomModel(c *gin.Context) (int, string) {
 
 	return http.StatusOK, AsJSON(&q)
 }
+
+//CreateRoomModel godoc
+//@Summary Create room model
+//@Description Creates a new room model with its tags. Sensors have to be created separately.
+//@Tags models
+//@Accept json
+//@Produce json
+//@Param room_model body model.RoomModel true "RoomModel"
+//@Success 201 {object} model.RoomModel
+//@Failure 400 {string} string "bad request"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models [post]
+func CreateRoomModel(c *gin.Context) (int, string) {
+	var q RoomModel
+	if err := c.ShouldBindJSON(&q); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return saveRoomModel(c, &q, 0, http.StatusCreated)
+}
+
+//UpdateRoomModel godoc
+//@Summary Replace room model
+//@Description Replaces all fields of a room model, its sensors remain untouched.
+//@Description Once floors were created for the room model, floors has to match their number.
+//@Description The tags are replaced if they are part of the body and kept otherwise.
+//@Tags models
+//@Accept json
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param room_model body model.RoomModel true "RoomModel"
+//@Success 200 {object} model.RoomModel
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id} [put]
+func UpdateRoomModel(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	var u RoomModel
+	if err := c.ShouldBindJSON(&u); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return saveRoomModel(c, &u, q.ID, http.StatusOK)
+}
+
+//PatchRoomModel godoc
+//@Summary Update room model
+//@Description Updates the passed fields of a room model, its sensors remain untouched.
+//@Description Once floors were created for the room model, floors has to match their number.
+//@Description Passed tags replace all current tags.
+//@Tags models
+//@Accept json
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param room_model body model.RoomModel true "RoomModel"
+//@Success 200 {object} model.RoomModel
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id} [patch]
+func PatchRoomModel(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	// fields which are not part of the body keep their current values
+	if err := c.ShouldBindJSON(&q); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return saveRoomModel(c, &q, q.ID, http.StatusOK)
+}
+
+//DeleteRoomModel godoc
+//@Summary Delete room model
+//@Description Deletes a room model together with its sensors, their data, bound schedules, maintenance windows,
+//@Description uploaded files, floors, rooms, assets and tags.
+//@Tags models
+//@Param id path int true "RoomModel ID"
+//@Success 204 {string} string "no content"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id} [delete]
+func DeleteRoomModel(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.Preload("Tags").Preload("Sensors").First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	ids := make([]uint, len(q.Sensors))
+	for i := range q.Sensors {
+		ids[i] = q.Sensors[i].ID
+	}
+
+	var files []ModelFile
+	DB.Where("room_model_id = ?", q.ID).Find(&files)
+
+	var keys []string
+	tx := DB.Begin()
+	err := deleteSensors(tx, ids)
+	if err == nil {
+		err = tx.Where("room_model_id = ?", q.ID).Delete(&MaintenanceWindow{}).Error
+	}
+	if err == nil {
+		keys, err = deleteModelFiles(tx, files)
+	}
+	if err == nil {
+		var floors []Floor
+		tx.Where("room_model_id = ?", q.ID).Find(&floors)
+		floorIDs := make([]uint, len(floors))
+		for i := range floors {
+			floorIDs[i] = floors[i].ID
+		}
+		err = deleteFloors(tx, floorIDs)
+	}
+	if err == nil {
+		err = tx.Where("room_model_id = ?", q.ID).Delete(&Asset{}).Error
+	}
+	if err == nil {
+		err = deleteTags(tx, &q, []uint{q.ID})
+	}
+	if err == nil {
+		err = tx.Where("room_model_id = ?", q.ID).Delete(&Grant{}).Error
+	}
+	if err == nil {
+		err = tx.Delete(&q).Error
+	}
+	if err == nil {
+		q.Sensors = nil
+		err = recordAudit(tx, c, "model", q.ID, &q, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	purgeStoredFiles(keys)
+
+	if len(ids) > 0 {
+		Live.invalidate(ids...)
+	}
+
+	return http.StatusNoContent, ""
+}
+
+//saveRoomModel validates and stores all editable fields of the model under the passed id, 0 creates a new model
+func saveRoomModel(c *gin.Context, q *RoomModel, id uint, status int) (int, string) {
+	q.ID = id
+	q.Sensors = nil
+
+	if err := q.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	// the floors are derived from the floors of the model once it has some
+	var floors int
+	if id != 0 {
+		DB.Model(&Floor{}).Where("room_model_id = ?", id).Count(&floors)
+	}
+	if floors > 0 && q.Floors != floors {
+		return http.StatusBadRequest, AsJSON(gin.H{"error":
+		fmt.Sprintf("'floors' must match the %d floors of the model value=%d.", floors, q.Floors)})
+	}
+
+	// tags are only replaced if they were passed
+	tags := q.Tags
+	q.Tags = nil
+	if err := ValidateTags(tags); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tx := DB.Begin()
+	var before interface{}
+	if id != 0 {
+		var current RoomModel
+		tx.Preload("Tags").First(&current, id)
+		before = auditJSON(&current)
+	}
+
+	var r RoomModel
+	err := tx.Save(q).Error
+	if err == nil && tags != nil {
+		err = replaceTags(tx, q, q.ID, tags)
+	}
+	if err == nil {
+		tx.Preload("Tags").First(&r, q.ID)
+		err = recordAudit(tx, c, "model", r.ID, before, &r)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	DB.Preload("Sensors").Preload("Sensors.Tags").First(&r, q.ID)
+	for i := range r.Sensors {
+		r.Sensors[i].LatestData = findLatestData(&r.Sensors[i])
+	}
+
+	return status, AsJSON(&r)
+}
+
+//QueryModelAnomalies godoc
+//@Summary Query model anomalies
+//@Description Query the anomalies of all sensors of a room model grouped by sensor, each with a summary containing
+//@Description the number of anomalies, the worst severity (deviation of the peak relative to the violated bound)
+//@Description and whether an anomaly is still going on at the sensor's latest reading.
+//@Tags models
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param maintenance query string false "Handling of maintenance windows [suppress, tag, ignore]"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} SensorAnomalies
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/anomalies [get]
+func QueryModelAnomalies(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.Preload("Sensors").First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	return querySensorsAnomalies(c, q.Sensors)
+}
+
+//SensorData contains the data of a single sensor of a room model
+type SensorData struct {
+	SensorID uint   `json:"sensor_id"`
+	Data     []Data `json:"data"`
+}
+
+//QueryModelData godoc
+//@Summary Query model data
+//@Description Query the data of all sensors of a room model which have the required tags, grouped by sensor.
+//@Description The parameters apply to every sensor as for the data of a single sensor.
+//@Tags models
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param limit query int false "Data Limit per sensor"
+//@Param density query int false "Include only every nth element [1-16]"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} SensorData
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/data [get]
+func QueryModelData(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.Preload("Sensors", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	queryParams := map[string]interface{}{
+		"start_date": "",
+		"end_date":   "",
+		"limit":      int64(1000),
+		"density":    int64(1),
+	}
+
+	err := fillQueryParams(c, &queryParams)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	density := int(queryParams["density"].(int64))
+	if density < 1 || density > 16 {
+		return http.StatusBadRequest, AsJSON(gin.H{"error":
+		fmt.Sprintf("Data density is out of its range [1-16] value=%d.", density)})
+	}
+
+	sensors, err := filterSensorsByTags(c, q.Sensors)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	r := make([]SensorData, len(sensors))
+	for i := range sensors {
+		r[i] = SensorData{SensorID: sensors[i].ID, Data: findSensorData(&sensors[i], queryParams)}
+	}
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//QueryModelAggregates godoc
+//@Summary Query model aggregates
+//@Description Query minimum, maximum and mean of the data of all sensors of a room model which have the required tags
+//@Description grouped by measurement unit.
+//@Tags models
+//@Produce json
+//@Param id path int true "RoomModel ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Success 200 {array} Aggregate
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/aggregates [get]
+func QueryModelAggregates(c *gin.Context) (int, string) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.Preload("Sensors").First(&q, id)
+	if q.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)})
+	}
+
+	return querySensorsAggregates(c, q.Sensors)
+}
+
+//querySensorsAnomalies parses the period, maintenance mode and tag filter and evaluates the anomalies of the passed
+//sensors which have the required tags
+func querySensorsAnomalies(c *gin.Context, sensors []Sensor) (int, string) {
+	queryParams := map[string]interface{}{
+		"start_date": "",
+		"end_date":   "",
+	}
+
+	err := fillQueryParams(c, &queryParams)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	mode, err := parseMaintenanceMode(c.Query("maintenance"))
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	sensors, err = filterSensorsByTags(c, sensors)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusOK, AsJSON(findModelAnomalies(sensors, queryParams["start_date"].(string),
+		queryParams["end_date"].(string), mode))
+}
+
+//findModelAnomalies evaluates the data of all passed sensors, which is loaded with a single query
+func findModelAnomalies(sensors []Sensor, startDate string, endDate string, mode MaintenanceMode) []SensorAnomalies {
+	result := make([]SensorAnomalies, 0, len(sensors))
+	if len(sensors) == 0 {
+		return result
+	}
+
+	ids := make([]uint, len(sensors))
+	configs := loadAnomalyConfigs(sensors, mode)
+	detectors := make(map[uint]*anomalyDetector, len(sensors))
+	for i := range sensors {
+		ids[i] = sensors[i].ID
+		detectors[sensors[i].ID] = newAnomalyDetector(&sensors[i], configs[sensors[i].ID])
+	}
+
+	// an anomaly is only active if the evaluated period reaches the sensor's latest reading
+	later := make(map[uint]bool)
+	if endDate != "" {
+		var laterIDs []uint
+		DB.Model(&Data{}).Where("sensor_id IN (?) AND date > ?", ids, endDate).Pluck("DISTINCT sensor_id", &laterIDs)
+		for _, id := range laterIDs {
+			later[id] = true
+		}
+	}
+
+	q := DB.Where("sensor_id IN (?)", ids)
+	if startDate != "" {
+		q = q.Where("date >= ?", startDate)
+	}
+	if endDate != "" {
+		q = q.Where("date <= ?", endDate)
+	}
+
+	var r []Data
+	q.Order("sensor_id").Order("date").Find(&r)
+
+	for i := range r {
+		detectors[r[i].SensorID].push(&r[i])
+	}
+
+	for i := range sensors {
+		d := detectors[sensors[i].ID]
+		active := d.active() && !later[sensors[i].ID]
+		anomalies := d.finish()
+		result = append(result, SensorAnomalies{
+			SensorID:  sensors[i].ID,
+			Summary:   summarizeAnomalies(anomalies, active),
+			Anomalies: anomalies,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+	"time"
+)
+
+//QueryBoundSchedules godoc
+//@Summary Query bound schedules
+//@Description Query the bound schedules in force of a sensor. The first schedule (by id) in force at the date of a
+//@Description reading and applying to it overrides the sensor's bounds for that reading.
+//@Tags sensors
+//@Produce json
+//@Param id path int true "Sensor ID"
+//@Param history query bool false "Include the deleted schedules"
+//@Success 200 {array} model.BoundSchedule
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id}/schedules [get]
+func QueryBoundSchedules(c *gin.Context) (int, string) {
+	var s Sensor
+	id := c.Param("id")
+	DB.First(&s, id)
+	if s.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %s not found.", id)})
+	}
+
+	history, err := parseBoolParam(c.Query("history"), false)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "history", Value: c.Query("history")}).Error()})
+	}
+
+	r := make([]BoundSchedule, 0)
+	q := DB.Where("sensor_id = ?", s.ID)
+	if !history {
+		q = q.Where("valid_to IS NULL")
+	}
+	q.Order("id").Find(&r)
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//CreateBoundSchedule godoc
+//@Summary Create bound schedule
+//@Description Creates a schedule which overrides the bounds of a sensor during a daily time range on selected weekdays
+//@Description (e.g. "mon,tue,wed"; empty for every day). Times use the format HH:MM in the time zone of the room model,
+//@Description an end time before the start time reaches into the next day. Unset bounds fall back to the sensor's bounds.
+//@Description The schedule applies to readings from valid_from on, valid_from defaults to now.
+//@Tags sensors
+//@Accept json
+//@Produce json
+//@Param id path int true "Sensor ID"
+//@Param bound_schedule body model.BoundSchedule true "BoundSchedule"
+//@Param valid_from query string false "Date from which the schedule applies"
+//@Success 201 {object} model.BoundSchedule
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id}/schedules [post]
+func CreateBoundSchedule(c *gin.Context) (int, string) {
+	var s Sensor
+	id := c.Param("id")
+	DB.First(&s, id)
+	if s.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %s not found.", id)})
+	}
+
+	validFrom := time.Now().UTC()
+	if v := c.Query("valid_from"); v != "" {
+		t, err := time.Parse(Layout, v)
+		if err != nil || t.After(validFrom) {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "valid_from", Value: v}).Error()})
+		}
+		validFrom = t
+	}
+
+	var b BoundSchedule
+	if err := c.ShouldBindJSON(&b); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	b.ID = 0
+	b.SensorID = s.ID
+	b.ValidFrom, b.ValidTo = &validFrom, nil
+
+	if err := b.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tx := DB.Begin()
+	err := tx.Create(&b).Error
+	if err == nil {
+		err = recordAudit(tx, c, "schedule", b.ID, nil, &b)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+	Live.invalidate(s.ID)
+
+	return http.StatusCreated, AsJSON(&b)
+}
+
+//DeleteBoundSchedule godoc
+//@Summary Delete bound schedule
+//@Description Deletes a single bound schedule of a sensor. The schedule stays in force for the readings before the
+//@Description deletion and is listed with the history of the schedules.
+//@Tags sensors
+//@Param id path int true "Sensor ID"
+//@Param schedule_id path int true "BoundSchedule ID"
+//@Success 204 {string} string "no content"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id}/schedules/{schedule_id} [delete]
+func DeleteBoundSchedule(c *gin.Context) (int, string) {
+	var b BoundSchedule
+	id := c.Param("schedule_id")
+	DB.Where("sensor_id = ? AND valid_to IS NULL", c.Param("id")).First(&b, id)
+	if b.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Schedule %s not found.", id)})
+	}
+
+	tx := DB.Begin()
+	err := tx.Model(&BoundSchedule{}).Where("id = ?", b.ID).Update("valid_to", time.Now().UTC()).Error
+	if err == nil {
+		err = recordAudit(tx, c, "schedule", b.ID, &b, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+	Live.invalidate(b.SensorID)
+
+	return http.StatusNoContent, ""
+}
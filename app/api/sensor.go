@@ -1,22 +1,45 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 	. "github.com/vi-sense/vi-sense/app/model"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type UpdateSensor struct {
-	MeshId        string  `json:"mesh_id"`
-	LowerBound    float64 `json:"lower_bound"`
-	UpperBound    float64 `json:"upper_bound"`
-	GradientBound float64 `json:"gradient_bound"`
+	MeshId          string  `json:"mesh_id"`
+	LowerBound      float64 `json:"lower_bound"`
+	UpperBound      float64 `json:"upper_bound"`
+	GradientBound   float64 `json:"gradient_bound"`
+	RoomModelID     uint    `json:"room_model_id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	MeasurementUnit string  `json:"measurement_unit"`
+	Range           string  `json:"range"`
+	RoomID          uint    `json:"room_id"`
+	AssetID         uint    `json:"asset_id"`
+	Tags            []Tag   `json:"tags"`
 }
 
+//AnomalyPreview is a candidate anomaly configuration in the format of UpdateSensor with additional detector options
+type AnomalyPreview struct {
+	LowerBound    *float64        `json:"lower_bound"`
+	UpperBound    *float64        `json:"upper_bound"`
+	GradientBound *float64        `json:"gradient_bound"`
+	Maintenance   MaintenanceMode `json:"maintenance"`
+}
+
+//configKeys are the fields of a sensor which are versioned in its configuration history
+var configKeys = []string{"upper_bound", "lower_bound", "gradient_bound", "mesh_id"}
+
 type ParamParseError struct {
 	Param string
 	Value string
@@ -29,34 +52,75 @@ func (e *ParamParseError) Error() string {
 	return fmt.Sprintf("Error parsing parameter '%s'.", e.Param)
 }
 
-type Anomaly struct {
-	Type      AnomalyType `json:"type"`
-	StartData *Data       `json:"start_data"`
-	EndData   *Data       `json:"end_data"`
-	PeakData  *Data       `json:"peak_data"`
-}
-
-type AnomalyType string
-
-const (
-	UpwardGradient   AnomalyType = "High Upward Gradient"
-	DownwardGradient AnomalyType = "High Downward Gradient"
-	AboveUpperLimit  AnomalyType = "Above Upper Limit"
-	BelowLowerLimit  AnomalyType = "Below Lower Limit"
-)
-
 //QuerySensors godoc
 //@Summary Query sensors
 //@Description Query all available sensors.
+//@Description The sensors can be filtered by room model, measurement unit, name (substring, case-insensitive),
+//@Description anomaly status and tags. A sensor's anomaly status is active if an anomaly is going on at its latest reading,
+//@Description only the readings of the 24 hours before it are evaluated.
+//@Description Each tag parameter is either key:value or only a key to match every value, all of them have to match.
+//@Description sort accepts a comma separated list of columns (e.g. room_model_id,-name), each prefixed with -
+//@Description to sort descending. The total number of sensors matching the filters is returned in the X-Total-Count header.
 //@Tags sensors
 //@Produce json
+//@Param room_model_id query int false "RoomModel ID"
+//@Param measurement_unit query string false "Measurement unit"
+//@Param name query string false "Part of the name"
+//@Param anomaly_status query string false "Anomaly status [active, inactive]"
+//@Param tag query []string false "Tags the sensors must have as key:value or key" collectionFormat(multi)
+//@Param sort query string false "Columns to sort by"
+//@Param limit query int false "Maximum number of sensors (0-1000, 0 for all)"
+//@Param offset query int false "Number of sensors to skip"
 //@Success 200 {array} model.Sensor
+//@Header 200 {integer} X-Total-Count "Total number of sensors"
 //@Failure 400 {string} string "bad request"
 //@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
 //@Router /sensors [get]
-func QuerySensors() (int, string) {
-	var r []Sensor
-	DB.Find(&r)
+func QuerySensors(c *gin.Context) (int, string) {
+	list, err := parseListParams(c, &Sensor{})
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	tags, err := parseTagFilter(c)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	q := accessOf(c).scope(tags.apply(DB.Model(&Sensor{}).Preload("Tags"), &Sensor{}), "room_model_id")
+	if id := c.Query("room_model_id"); id != "" {
+		modelID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "room_model_id", Value: id}).Error()})
+		}
+		q = q.Where("room_model_id = ?", modelID)
+	}
+	if unit := c.Query("measurement_unit"); unit != "" {
+		q = q.Where("measurement_unit = ?", unit)
+	}
+	if name := c.Query("name"); name != "" {
+		q = q.Where("LOWER(name) LIKE ? ESCAPE '\\'", containsPattern(name))
+	}
+
+	r := make([]Sensor, 0)
+	switch status := c.Query("anomaly_status"); status {
+	case "":
+		if err := list.paginate(c, q, &Sensor{}).Find(&r).Error; err != nil {
+			return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+		}
+	case "active", "inactive":
+		// the status is evaluated after loading, so the page is selected afterwards
+		if err := list.sorted(q).Find(&r).Error; err != nil {
+			return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+		}
+		r = filterByAnomalyStatus(r, status == "active")
+		start, end := list.window(c, len(r))
+		r = r[start:end]
+	default:
+		return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "anomaly_status", Value: status}).Error()})
+	}
 
 	for i := range r {
 		r[i].LatestData = findLatestData(&r[i])
@@ -65,6 +129,24 @@ func QuerySensors() (int, string) {
 	return http.StatusOK, AsJSON(&r)
 }
 
+//filterByAnomalyStatus keeps the sensors which have an anomaly going on at their latest reading if active is set
+//or those without one otherwise
+func filterByAnomalyStatus(sensors []Sensor, active bool) []Sensor {
+	latest := make([]Data, len(sensors))
+	for i := range sensors {
+		latest[i] = findLatestData(&sensors[i])
+	}
+	anomalies := currentAnomalies(sensors, latest, SuppressMaintenance)
+
+	r := make([]Sensor, 0, len(sensors))
+	for i := range sensors {
+		if (len(anomalies[i]) > 0) == active {
+			r = append(r, sensors[i])
+		}
+	}
+	return r
+}
+
 //QuerySensor godoc
 //@Summary Query sensor
 //@Description Query a single sensor by id
@@ -75,11 +157,13 @@ func QuerySensors() (int, string) {
 //@Failure 400 {string} string "bad request"
 //@Failure 404 {string} string "not found"
 //@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
 //@Router /sensors/{id} [get]
 func QuerySensor(c *gin.Context) (int, string) {
 	var r Sensor
 	id := c.Param("id")
-	DB.First(&r, id)
+	DB.Preload("Tags").First(&r, id)
 	if r.ID == 0 {
 		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %s not found.", id)})
 	}
@@ -101,6 +185,8 @@ func QuerySensor(c *gin.Context) (int, string) {
 //@Failure 400 {string} string "bad request"
 //@Failure 404 {string} string "not found"
 //@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
 //@Router /sensors/{id}/data [get]
 func QuerySensorData(c *gin.Context) (int, string) {
 	id := c.Param("id")
@@ -124,10 +210,22 @@ func QuerySensorData(c *gin.Context) (int, string) {
 		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
 	}
 
+	density := int(queryParams["density"].(int64))
+	if density < 1 || density > 16 {
+		return http.StatusBadRequest, AsJSON(gin.H{"error":
+		fmt.Sprintf("Data density is out of its range [1-16] value=%d.", density)})
+	}
+
+	return http.StatusOK, AsJSON(findSensorData(&s, queryParams))
+}
+
+//findSensorData returns the data of the sensor selected by the query parameters of QuerySensorData
+//in chronological order
+func findSensorData(s *Sensor, queryParams map[string]interface{}) []Data {
 	// query sensor data within defined time period
 	r := make([]Data, 0)
 
-	q := DB.Where("sensor_id = ?", id)
+	q := DB.Where("sensor_id = ?", s.ID)
 
 	if queryParams["start_date"] != "" && queryParams["end_date"] == "" {
 		q = q.Where("date >= ?", queryParams["start_date"]).Limit(queryParams["limit"]).Find(&r)
@@ -146,11 +244,6 @@ func QuerySensorData(c *gin.Context) (int, string) {
 	})
 
 	density := int(queryParams["density"].(int64))
-	if density < 1 || density > 16 {
-		return http.StatusBadRequest, AsJSON(gin.H{"error":
-		fmt.Sprintf("Data density is out of its range [1-16] value=%d.", density)})
-	}
-
 	result := make([]Data, 0)
 	if density != 1 {
 		for i := 0; i < len(r); i += density {
@@ -160,7 +253,7 @@ func QuerySensorData(c *gin.Context) (int, string) {
 		result = r
 	}
 
-	return http.StatusOK, AsJSON(result)
+	return result
 }
 
 //QuerySensor godoc
@@ -171,10 +264,13 @@ func QuerySensorData(c *gin.Context) (int, string) {
 //@Param id path int true "Sensor ID"
 //@Param start_date query string false "Start Date"
 //@Param end_date query string false "End Date"
+//@Param maintenance query string false "Handling of maintenance windows [suppress, tag, ignore]"
 //@Success 200 {array} Anomaly
 //@Failure 400 {string} string "bad request"
 //@Failure 404 {string} string "not found"
 //@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
 //@Router /sensors/{id}/anomalies [get]
 func QueryAnomalies(c *gin.Context) (int, string) {
 	id := c.Param("id")
@@ -196,110 +292,220 @@ func QueryAnomalies(c *gin.Context) (int, string) {
 		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
 	}
 
-	q := DB.Where("sensor_id = ?", id)
+	mode, err := parseMaintenanceMode(c.Query("maintenance"))
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	r := findDataInPeriod(&s, queryParams["start_date"].(string), queryParams["end_date"].(string))
+
+	anomalies := findAnomalies(&s, r, loadAnomalyConfig(&s, mode))
 
-	if queryParams["start_date"] != "" {
-		q = q.Where("date >= ?", queryParams["start_date"])
+	return http.StatusOK, AsJSON(anomalies)
+}
+
+//PreviewAnomalies godoc
+//@Summary Preview anomalies
+//@Description Evaluates a candidate anomaly configuration over the sensor's data without persisting it.
+//@Description Bounds which are not part of the body are taken from the stored sensor, null removes a bound.
+//@Description The detector option 'maintenance' defines the handling of maintenance windows [suppress, tag, ignore].
+//@Tags sensors
+//@Accept json
+//@Produce json
+//@Param id path int true "Sensor ID"
+//@Param start_date query string false "Start Date"
+//@Param end_date query string false "End Date"
+//@Param preview body AnomalyPreview true "AnomalyPreview"
+//@Success 200 {array} Anomaly
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id}/anomalies/preview [post]
+func PreviewAnomalies(c *gin.Context) (int, string) {
+	id := c.Param("id")
+
+	queryParams := map[string]interface{}{
+		"start_date": "",
+		"end_date":   "",
 	}
 
-	if queryParams["end_date"] != "" {
-		q = q.Where("date <= ?", queryParams["end_date"])
+	// check if sensor exists
+	var s Sensor
+	DB.First(&s, id)
+	if s.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor '%s' not found.", id)})
 	}
 
-	var r []Data
-	q.Find(&r)
+	err := fillQueryParams(c, &queryParams)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
 
-	anomalies := make([]Anomaly, 0)
-	currAnomalies := make([]*Anomaly, 4)
+	var i map[string]interface{}
+	if err := c.ShouldBindJSON(&i); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
 
-	for i := 0; i < len(r); i++ {
-		if s.LowerBound != nil && r[i].Value < *s.LowerBound {
-			// new anomaly occurred
-			if currAnomalies[0] == nil {
-				currAnomalies[0] = &Anomaly{Type: BelowLowerLimit, StartData: &r[i], EndData: nil}
-				currAnomalies[0].PeakData = &r[i]
-				// anomaly goes on
-			} else {
-				// new peak value
-				if r[i].Value < currAnomalies[0].PeakData.Value {
-					currAnomalies[0].PeakData = &r[i]
-				}
-				currAnomalies[0].EndData = &r[i]
-			}
+	m, ok := i["maintenance"].(string)
+	if _, exists := i["maintenance"]; exists && !ok {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "maintenance"}).Error()})
+	}
+	mode, err := parseMaintenanceMode(m)
+	if err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
 
-			// anomaly ended
-		} else if currAnomalies[0] != nil {
-			anomalies = append(anomalies, *currAnomalies[0])
-			currAnomalies[0] = nil
-		}
+	if err := validateUpdateValues(i); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
 
-		if s.UpperBound != nil && r[i].Value > *s.UpperBound {
-			// new anomaly occurred
-			if currAnomalies[1] == nil {
-				currAnomalies[1] = &Anomaly{Type: AboveUpperLimit, StartData: &r[i], EndData: nil}
-				currAnomalies[1].PeakData = &r[i]
-				// anomaly goes on
-			} else {
-				// new peak value
-				if r[i].Value > currAnomalies[1].PeakData.Value {
-					currAnomalies[1].PeakData = &r[i]
-				}
-				currAnomalies[1].EndData = &r[i]
-			}
-			// anomaly ended
-		} else if currAnomalies[1] != nil {
-			anomalies = append(anomalies, *currAnomalies[1])
-			currAnomalies[1] = nil
-		}
+	// the candidate configuration is applied to a copy which is never saved and replaces all earlier versions
+	candidate := s
+	applyUpdateValues(&candidate, i)
+	config := loadAnomalyConfig(&s, mode)
+	config.versions = nil
 
-		if s.GradientBound != nil {
-			if r[i].Gradient >= 0 && r[i].Gradient > *s.GradientBound {
-				// new anomaly occurred
-				if currAnomalies[2] == nil {
-					currAnomalies[2] = &Anomaly{Type: UpwardGradient, StartData: &r[i], EndData: nil}
-					currAnomalies[2].PeakData = &r[i]
-					// anomaly goes on
-				} else {
-					// new peak gradient
-					if r[i].Gradient > currAnomalies[2].PeakData.Gradient {
-						currAnomalies[2].PeakData = &r[i]
-					}
-					currAnomalies[2].EndData = &r[i]
-				}
-				// anomaly ended
-			} else if currAnomalies[2] != nil {
-				anomalies = append(anomalies, *currAnomalies[2])
-				currAnomalies[2] = nil
-			}
+	r := findDataInPeriod(&s, queryParams["start_date"].(string), queryParams["end_date"].(string))
 
-			if r[i].Gradient < 0 && r[i].Gradient < -*s.GradientBound {
-				// new anomaly occurred
-				if currAnomalies[3] == nil {
-					currAnomalies[3] = &Anomaly{Type: DownwardGradient, StartData: &r[i], EndData: nil}
-					currAnomalies[3].PeakData = &r[i]
-					// anomaly goes on
-				} else {
-					// new peak gradient
-					if r[i].Gradient < currAnomalies[3].PeakData.Gradient {
-						currAnomalies[3].PeakData = &r[i]
-					}
-					currAnomalies[3].EndData = &r[i]
-				}
-				// anomaly ended
-			} else if currAnomalies[3] != nil {
-				anomalies = append(anomalies, *currAnomalies[3])
-				currAnomalies[3] = nil
-			}
+	return http.StatusOK, AsJSON(findAnomalies(&candidate, r, config))
+}
+
+//CreateSensor godoc
+//@Summary Create sensor
+//@Description Creates a new sensor inside an existing room model.
+//@Description The mesh id has to exist in the model's uploaded glTF/GLB file unless force is set.
+//@Tags sensors
+//@Accept json
+//@Produce json
+//@Param sensor body model.Sensor true "Sensor"
+//@Param force query bool false "Accept a mesh id which does not exist in the model's glTF/GLB file"
+//@Success 201 {object} model.Sensor
+//@Failure 400 {string} string "bad request"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors [post]
+func CreateSensor(c *gin.Context) (int, string) {
+	var r Sensor
+	if err := c.ShouldBindJSON(&r); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	r.ID = 0
+	r.LatestData = Data{}
+
+	if strings.TrimSpace(r.Name) == "" {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": "'name' must not be empty."})
+	}
+
+	if err := ValidateTags(r.Tags); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	var q RoomModel
+	DB.First(&q, r.RoomModelID)
+	if q.ID == 0 {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": fmt.Sprintf("Model %d not found.", r.RoomModelID)})
+	}
+
+	if r.MeshID != nil {
+		if status, err := checkMeshID(c, q.ID, *r.MeshID); err != nil {
+			return status, AsJSON(gin.H{"error": err.Error()})
 		}
 	}
 
-	for _, a := range currAnomalies {
-		if a != nil {
-			anomalies = append(anomalies, *a)
+	if r.RoomID != nil && findRoomModelOfRoom(*r.RoomID) != q.ID {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": fmt.Sprintf("Room %d is not part of model %d.", *r.RoomID, q.ID)})
+	}
+
+	if r.AssetID != nil && findRoomModelOfAsset(*r.AssetID) != q.ID {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": fmt.Sprintf("Asset %d is not part of model %d.", *r.AssetID, q.ID)})
+	}
+
+	tx := DB.Begin()
+	err := tx.Create(&r).Error
+	if err == nil {
+		err = recordSensorConfig(tx, nil, r.ID, time.Now().UTC())
+	}
+	if err == nil {
+		err = recordAudit(tx, c, "sensor", r.ID, nil, &r)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusCreated, AsJSON(&r)
+}
+
+//DeleteSensor godoc
+//@Summary Delete sensor
+//@Description Deletes a sensor together with its bound schedules and maintenance windows.
+//@Description Its data is deleted as well unless move_data_to passes another sensor, e.g. the replacement of the
+//@Description deleted one, the data is reassigned to.
+//@Tags sensors
+//@Param id path int true "Sensor ID"
+//@Param move_data_to query int false "ID of the sensor the data is reassigned to"
+//@Success 204 {string} string "no content"
+//@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id} [delete]
+func DeleteSensor(c *gin.Context) (int, string) {
+	var r Sensor
+	id := c.Param("id")
+	DB.Preload("Tags").First(&r, id)
+	if r.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %s not found.", id)})
+	}
+
+	var target Sensor
+	if to := c.Query("move_data_to"); to != "" {
+		DB.First(&target, to)
+		if target.ID == 0 || target.ID == r.ID {
+			return http.StatusBadRequest, AsJSON(gin.H{"error":
+			(&ParamParseError{Param: "move_data_to", Value: to}).Error()})
+		}
+		if !accessOf(c).permits(target.RoomModelID) {
+			return http.StatusForbidden, AsJSON(gin.H{"error":
+			fmt.Sprintf("Model %d is not granted.", target.RoomModelID)})
 		}
 	}
 
-	return http.StatusOK, AsJSON(anomalies)
+	tx := DB.Begin()
+	var err error
+	if target.ID != 0 {
+		err = tx.Model(&Data{}).Where("sensor_id = ?", r.ID).Update("sensor_id", target.ID).Error
+	}
+	if err == nil {
+		err = deleteSensors(tx, []uint{r.ID})
+	}
+	if err == nil {
+		err = recordAudit(tx, c, "sensor", r.ID, &r, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if target.ID != 0 {
+		Live.invalidate(target.ID)
+	}
+	Live.invalidate(r.ID)
+
+	return http.StatusNoContent, ""
 }
 
 func fillQueryParams(c *gin.Context, m *map[string]interface{}) error {
@@ -335,26 +541,45 @@ func fillQueryParams(c *gin.Context, m *map[string]interface{}) error {
 }
 
 //Patch	Sensor godoc
-//@Summary Update sensor preferences
-//@Description Updates the mesh id and anomaly preferences of a single sensor.
+//@Summary Update sensor
+//@Description Updates the mesh id, anomaly preferences, descriptive fields, the room model, the room and the asset
+//@Description of a single sensor. Passed tags replace all current tags.
+//@Description The mesh id has to exist in the model's uploaded glTF/GLB file unless force is set.
+//@Description Changed bounds or meshes start a new version of the sensor's configuration which applies to readings
+//@Description from valid_from on. valid_from defaults to now and must not be before the latest version, a version
+//@Description starting at the same date is replaced.
 //@Tags sensors
 //@Accept json
 //@Produce json
 //@Param id path int true "SensorId"
 //@Param update_sensor body UpdateSensor true "UpdateSensor"
+//@Param force query bool false "Accept a mesh id which does not exist in the model's glTF/GLB file"
+//@Param valid_from query string false "Date from which changed bounds and meshes apply"
 //@Success 200 {object} model.Sensor
 //@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
 //@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
 //@Router /sensors/{id} [patch]
 func PatchSensor(c *gin.Context) (int, string) {
 	var r Sensor
 	id := c.Param("id")
-	DB.First(&r, id)
+	DB.Preload("Tags").First(&r, id)
 
 	if r.ID == 0 {
 		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %s not found.", id)})
 	}
 
+	validFrom := time.Now().UTC()
+	if v := c.Query("valid_from"); v != "" {
+		t, err := time.Parse(Layout, v)
+		if err != nil || t.After(validFrom) {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "valid_from", Value: v}).Error()})
+		}
+		validFrom = t
+	}
+
 	var i map[string]interface{}
 	if err := c.ShouldBindJSON(&i); err != nil {
 		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
@@ -364,8 +589,72 @@ func PatchSensor(c *gin.Context) (int, string) {
 		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
 	}
 
-	DB.Model(&r).Update(i)
+	// the mesh has to exist in the sensor's new or current model
+	modelID, modelChanged := i["room_model_id"].(uint)
+	if !modelChanged {
+		modelID = r.RoomModelID
+	} else if !accessOf(c).permits(modelID) {
+		return http.StatusForbidden, AsJSON(gin.H{"error": fmt.Sprintf("Model %d is not granted.", modelID)})
+	}
+	meshID, meshChanged := i["mesh_id"].(int64)
+	if !meshChanged && modelChanged && r.MeshID != nil {
+		meshID, meshChanged = *r.MeshID, true
+	}
+	if meshChanged {
+		if status, err := checkMeshID(c, modelID, meshID); err != nil {
+			return status, AsJSON(gin.H{"error": err.Error()})
+		}
+	}
+
+	// the room and the asset have to be part of the sensor's new or current model, moving the sensor to another
+	// model without a new room or asset unassigns it from the current one
+	current := map[string]*uint{"room_id": r.RoomID, "asset_id": r.AssetID}
+	for k, a := range assignments {
+		if parentID, ok := i[k].(uint); ok {
+			if a.model(parentID) != modelID {
+				return http.StatusBadRequest, AsJSON(gin.H{"error":
+				fmt.Sprintf("%s %d is not part of model %d.", a.name, parentID, modelID)})
+			}
+		} else if _, ok := i[k]; !ok && modelChanged && current[k] != nil && a.model(*current[k]) != modelID {
+			i[k] = nil
+		}
+	}
 
+	// tags are stored separately and replace all current tags
+	tags, tagsChanged := i["tags"].([]Tag)
+	delete(i, "tags")
+
+	tx := DB.Begin()
+	var previous Sensor
+	tx.Preload("Tags").First(&previous, r.ID)
+	before := auditJSON(&previous)
+	err := tx.Model(&r).Update(i).Error
+	for _, k := range configKeys {
+		if _, ok := i[k]; ok && err == nil {
+			err = recordSensorConfig(tx, &previous, r.ID, validFrom)
+			break
+		}
+	}
+	if err == nil && tagsChanged {
+		err = replaceTags(tx, &r, r.ID, tags)
+	}
+	if err == nil {
+		tx.Preload("Tags").First(&r, r.ID)
+		err = recordAudit(tx, c, "sensor", r.ID, before, &r)
+	}
+	if err != nil {
+		tx.Rollback()
+		if e, ok := err.(*ConfigBackdatedError); ok {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": e.Error()})
+		}
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	Live.invalidate(r.ID)
 	r.LatestData = findLatestData(&r)
 
 	return http.StatusOK, AsJSON(&r)
@@ -392,6 +681,14 @@ func parseFloatParam(s string, def float64) (float64, error) {
 	return strconv.ParseFloat(s, 64)
 }
 
+func parseBoolParam(s string, def bool) (bool, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	return strconv.ParseBool(s)
+}
+
 func parseIntParam(s string, def int64) (int64, error) {
 	if s == "" {
 		return def, nil
@@ -400,6 +697,16 @@ func parseIntParam(s string, def int64) (int64, error) {
 	return strconv.ParseInt(s, 10, 64)
 }
 
+//assignments contains the entities a sensor can be assigned to by their update key, model returns the id of the
+//room model an entity belongs to or 0 if it does not exist
+var assignments = map[string]struct {
+	name  string
+	model func(uint) uint
+}{
+	"room_id":  {"Room", findRoomModelOfRoom},
+	"asset_id": {"Asset", findRoomModelOfAsset},
+}
+
 func validateUpdateValues(m map[string]interface{}) error {
 	var unknown []string
 
@@ -424,6 +731,65 @@ func validateUpdateValues(m map[string]interface{}) error {
 					Param: k,
 				}
 			}
+
+		case "name":
+			if n, ok := v.(string); !ok || strings.TrimSpace(n) == "" {
+				return &ParamParseError{
+					Param: k,
+				}
+			}
+
+		case "description", "measurement_unit", "range":
+			if _, ok := v.(string); !ok {
+				return &ParamParseError{
+					Param: k,
+				}
+			}
+
+		case "room_model_id":
+			f, ok := v.(float64)
+			if !ok || f < 1 || f != math.Trunc(f) {
+				return &ParamParseError{
+					Param: k,
+				}
+			}
+
+			var q RoomModel
+			DB.First(&q, uint(f))
+			if q.ID == 0 {
+				return fmt.Errorf("Model %d not found.", uint(f))
+			}
+			m[k] = q.ID
+
+		case "tags":
+			// the tags are converted by their json representation
+			var tags []Tag
+			if b, err := json.Marshal(v); err != nil || v == nil || json.Unmarshal(b, &tags) != nil {
+				return &ParamParseError{
+					Param: k,
+				}
+			}
+			if err := ValidateTags(tags); err != nil {
+				return err
+			}
+			m[k] = tags
+
+		case "room_id", "asset_id":
+			if v != nil {
+				f, ok := v.(float64)
+				if !ok || f < 1 || f != math.Trunc(f) {
+					return &ParamParseError{
+						Param: k,
+					}
+				}
+
+				a := assignments[k]
+				if a.model(uint(f)) == 0 {
+					return fmt.Errorf("%s %d not found.", a.name, uint(f))
+				}
+				m[k] = uint(f)
+			}
+
 		default:
 			unknown = append(unknown, k)
 		}
@@ -436,8 +802,76 @@ func validateUpdateValues(m map[string]interface{}) error {
 	return nil
 }
 
+//applyUpdateValues sets the validated bounds of an update on the sensor without saving it
+func applyUpdateValues(s *Sensor, m map[string]interface{}) {
+	for k, v := range m {
+		var f *float64
+		if v != nil {
+			if value, ok := v.(float64); ok {
+				f = &value
+			}
+		}
+
+		switch k {
+		case "lower_bound":
+			s.LowerBound = f
+		case "upper_bound":
+			s.UpperBound = f
+		case "gradient_bound":
+			s.GradientBound = f
+		}
+	}
+}
+
+//deleteSensors deletes the sensors with the passed ids together with their data, bound schedules, maintenance windows
+//and tags
+func deleteSensors(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := tx.Where("sensor_id IN (?)", ids).Delete(&Data{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("sensor_id IN (?)", ids).Delete(&BoundSchedule{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("sensor_id IN (?)", ids).Delete(&SensorConfig{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("sensor_id IN (?)", ids).Delete(&MaintenanceWindow{}).Error; err != nil {
+		return err
+	}
+
+	if err := deleteTags(tx, &Sensor{}, ids); err != nil {
+		return err
+	}
+
+	return tx.Where("id IN (?)", ids).Delete(&Sensor{}).Error
+}
+
 func findLatestData(s *Sensor) Data {
 	var d Data
 	DB.Where("sensor_id = ?", s.ID).Order("date desc").First(&d)
 	return d
 }
+
+//findDataInPeriod returns the data of a sensor in chronological order, start and end date are optional
+func findDataInPeriod(s *Sensor, startDate string, endDate string) []Data {
+	q := DB.Where("sensor_id = ?", s.ID)
+
+	if startDate != "" {
+		q = q.Where("date >= ?", startDate)
+	}
+
+	if endDate != "" {
+		q = q.Where("date <= ?", endDate)
+	}
+
+	r := make([]Data, 0)
+	q.Order("date").Find(&r)
+	return r
+}
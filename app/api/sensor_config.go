@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+	"time"
+)
+
+//QuerySensorConfigs godoc
+//@Summary Query sensor configuration history
+//@Description Query the versions of the bounds and the mesh of a sensor in chronological order. Every change of them
+//@Description ends the current version and starts a new one, the anomalies of a reading are evaluated with the
+//@Description version in force at its date. If at is set only the version in force at that date is returned.
+//@Tags sensors
+//@Produce json
+//@Param id path int true "Sensor ID"
+//@Param at query string false "Date the version was in force at"
+//@Success 200 {array} model.SensorConfig
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /sensors/{id}/configs [get]
+func QuerySensorConfigs(c *gin.Context) (int, string) {
+	var s Sensor
+	id := c.Param("id")
+	DB.First(&s, id)
+	if s.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Sensor %s not found.", id)})
+	}
+
+	r := findSensorConfigs(&s)
+	if at := c.Query("at"); at != "" {
+		t, err := time.Parse(Layout, at)
+		if err != nil {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": (&ParamParseError{Param: "at", Value: at}).Error()})
+		}
+
+		effective := make([]SensorConfig, 0, 1)
+		for i := range r {
+			if r[i].Applies(t) {
+				effective = append(effective, r[i])
+			}
+		}
+		r = effective
+	}
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//findSensorConfigs returns the stored versions of the sensor's configuration in chronological order; sensors which
+//have not been changed since versioning was introduced get a single version with their current configuration
+func findSensorConfigs(s *Sensor) []SensorConfig {
+	r := make([]SensorConfig, 0)
+	DB.Where("sensor_id = ?", s.ID).Order("id").Find(&r)
+	if len(r) == 0 {
+		r = append(r, NewSensorConfig(s, nil))
+	}
+	return r
+}
+
+//ConfigBackdatedError is returned if a change of a sensor's configuration is dated before its latest version
+type ConfigBackdatedError struct {
+	Latest time.Time
+}
+
+func (e *ConfigBackdatedError) Error() string {
+	return fmt.Sprintf("'valid_from' must not be before the latest configuration version from %s.",
+		e.Latest.Format(Layout))
+}
+
+//recordSensorConfig ends the latest version of the sensor's configuration at the passed date and starts a new one if
+//the bounds or the mesh changed, a version starting at the date is replaced; previous is the sensor before the change
+//or nil if it has just been created. Earlier versions are never changed, a date before the latest version fails with
+//a ConfigBackdatedError.
+func recordSensorConfig(tx *gorm.DB, previous *Sensor, sensorID uint, at time.Time) error {
+	var s Sensor
+	if err := tx.First(&s, sensorID).Error; err != nil {
+		return err
+	}
+	if previous == nil {
+		v := NewSensorConfig(&s, nil)
+		return tx.Create(&v).Error
+	}
+
+	var current SensorConfig
+	tx.Where("sensor_id = ?", s.ID).Order("id desc").First(&current)
+	if current.ID == 0 {
+		// the configuration before the first change applies to all earlier readings
+		current = NewSensorConfig(previous, nil)
+	}
+
+	if current.ValidFrom != nil && at.Before(*current.ValidFrom) {
+		return &ConfigBackdatedError{Latest: *current.ValidFrom}
+	}
+	if !current.Differs(&s) {
+		return nil
+	}
+
+	v := NewSensorConfig(&s, &at)
+	if current.ValidFrom != nil && at.Equal(*current.ValidFrom) {
+		v.ID = current.ID
+		return tx.Save(&v).Error
+	}
+
+	current.ValidTo = &at
+	if err := tx.Save(&current).Error; err != nil {
+		return err
+	}
+	return tx.Create(&v).Error
+}
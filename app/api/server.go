@@ -0,0 +1,92 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/vi-sense/vi-sense/app/config"
+	"io/ioutil"
+	"net"
+	"net/http"
+)
+
+//Serve runs the HTTP and the HTTPS server of the configuration until one of them fails. If HTTPS can not be set up
+//an error is returned unless the fallback to HTTP only is allowed.
+func Serve(c config.Server, handler http.Handler) error {
+	if c.TLSAddr == "" {
+		fmt.Printf("[i] Serving HTTP on %s.\n", c.Addr)
+		return newServer(c, c.Addr, handler).ListenAndServe()
+	}
+
+	tlsConfig, err := NewTLSConfig(c)
+	if err != nil {
+		if !c.Fallback {
+			return fmt.Errorf("failed to set up HTTPS: %s", err.Error())
+		}
+		fmt.Println("[!] Failed to set up HTTPS, serving HTTP only:", err)
+		return newServer(c, c.Addr, handler).ListenAndServe()
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		s := newServer(c, c.TLSAddr, handler)
+		s.TLSConfig = tlsConfig
+		fmt.Printf("[i] Serving HTTPS on %s.\n", c.TLSAddr)
+		errs <- s.ListenAndServeTLS("", "")
+	}()
+	if c.Addr != "" {
+		go func() {
+			h := handler
+			if c.Redirect {
+				h = RedirectToHTTPS(c.TLSAddr)
+			}
+			fmt.Printf("[i] Serving HTTP on %s.\n", c.Addr)
+			errs <- newServer(c, c.Addr, h).ListenAndServe()
+		}()
+	}
+	return <-errs
+}
+
+func newServer(c config.Server, addr string, handler http.Handler) *http.Server {
+	return &http.Server{Addr: addr, Handler: handler, ReadTimeout: c.ReadTimeout, WriteTimeout: c.WriteTimeout}
+}
+
+//NewTLSConfig loads the certificate and the CA certificates client certificates are verified with. Clients may
+//connect without a certificate, a certificate which is presented has to be valid.
+func NewTLSConfig(c config.Server) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	r := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: c.MinTLSVersion}
+
+	if c.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		r.ClientCAs = x509.NewCertPool()
+		if !r.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + c.ClientCAFile)
+		}
+		r.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return r, nil
+}
+
+//RedirectToHTTPS returns a handler permanently redirecting all requests to the same host at the port of the HTTPS
+//address, the port is omitted if it is 443
+func RedirectToHTTPS(tlsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host, _, err := net.SplitHostPort(req.Host)
+		if err != nil {
+			host = req.Host
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, req, "https://"+host+req.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
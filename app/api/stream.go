@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//StreamRoomModel godoc
+//@Summary Stream room model
+//@Description Streams the latest values and anomaly status changes of all sensors of a room model as Server-Sent Events.
+//@Description The stream starts with a status event per sensor containing its latest reading and the anomaly going on
+//@Description at it within the 24 hours before, followed by a data event for every new reading and anomaly_start and
+//@Description anomaly_end events.
+//@Description A client reconnecting with the id of the last received event in the Last-Event-ID header or the
+//@Description last_event_id parameter receives the events it missed instead of the status events, as long as they are
+//@Description still known. A comment is sent every 30 seconds as heartbeat. Clients which do not keep up with the
+//@Description events are sent an error event and disconnected.
+//@Tags models
+//@Produce text/event-stream
+//@Param id path int true "RoomModel ID"
+//@Param Last-Event-ID header int false "ID of the last received event"
+//@Param last_event_id query int false "ID of the last received event for clients which can not set headers"
+//@Success 200 {object} Event
+//@Failure 400 {string} string "bad request"
+//@Failure 404 {string} string "not found"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /models/{id}/stream [get]
+func StreamRoomModel(c *gin.Context) {
+	var q RoomModel
+	id := c.Param("id")
+	DB.First(&q, id)
+	if q.ID == 0 {
+		c.String(http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("Model %s not found.", id)}))
+		return
+	}
+
+	param, lastEventID := "Last-Event-ID", c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		param, lastEventID = "last_event_id", c.Query("last_event_id")
+	}
+	var after uint64
+	if lastEventID != "" {
+		var err error
+		if after, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			c.String(http.StatusBadRequest, AsJSON(gin.H{"error":
+			(&ParamParseError{Param: param, Value: lastEventID}).Error()}))
+			return
+		}
+	}
+
+	sub, events, last, ok := Live.subscribeAfter([]uint{q.ID}, nil, after)
+	defer Live.unsubscribe(sub)
+	if lastEventID == "" || !ok {
+		events = findModelStatus(&q, last)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// keeps proxies like nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, e := range events {
+		if writeEvent(c.Writer, e) != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case e, ok := <-sub.events:
+			if !ok {
+				if sub.dropped {
+					_ = writeEvent(c.Writer, Event{Type: ErrorEvent, Error: "The client did not keep up with the events."})
+					c.Writer.Flush()
+				}
+				return
+			}
+			err = writeEvent(c.Writer, e)
+		case <-heartbeat.C:
+			_, err = io.WriteString(c.Writer, ": heartbeat\n\n")
+		case <-c.Request.Context().Done():
+			return
+		}
+
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+//findModelStatus returns a status event with the latest reading and the current anomaly of every sensor of the model
+func findModelStatus(q *RoomModel, lastID uint64) []Event {
+	var sensors []Sensor
+	DB.Where("room_model_id = ?", q.ID).Order("id").Find(&sensors)
+
+	latest := make([]Data, len(sensors))
+	for i := range sensors {
+		latest[i] = findLatestData(&sensors[i])
+	}
+
+	events := make([]Event, len(sensors))
+	anomalies := currentAnomalies(sensors, latest, SuppressMaintenance)
+	for i := range sensors {
+		events[i] = Event{ID: lastID, Type: StatusEvent, RoomModelID: q.ID, SensorID: sensors[i].ID}
+		if latest[i].ID != 0 {
+			events[i].Data = &latest[i]
+		}
+		if n := len(anomalies[i]); n > 0 {
+			events[i].Anomaly = &anomalies[i][n-1]
+		}
+	}
+	return events
+}
+
+//writeEvent writes the event in the text/event-stream format, events without id do not change the client's last id
+func writeEvent(w io.Writer, e Event) error {
+	if e.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", e.ID); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, AsJSON(e))
+	return err
+}
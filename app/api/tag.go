@@ -0,0 +1,105 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"strings"
+)
+
+//tagFilter contains the conditions of all tag query parameters, each one has to be fulfilled
+type tagFilter []tagCondition
+
+//tagCondition requires a tag with the key and, if set, the value
+type tagCondition struct {
+	key   string
+	value *string
+}
+
+//parseTagFilter parses the repeatable tag query parameter, each one is either key:value or only a key
+//to match every value
+func parseTagFilter(c *gin.Context) (tagFilter, error) {
+	var f tagFilter
+	for _, t := range c.QueryArray("tag") {
+		parts := strings.SplitN(t, ":", 2)
+		condition := tagCondition{key: strings.TrimSpace(parts[0])}
+		if condition.key == "" {
+			return nil, &ParamParseError{Param: "tag", Value: t}
+		}
+		if len(parts) == 2 {
+			condition.value = &parts[1]
+		}
+		f = append(f, condition)
+	}
+	return f, nil
+}
+
+//apply restricts the query on the owners of the tags to those having all required tags
+func (f tagFilter) apply(q *gorm.DB, owner interface{}) *gorm.DB {
+	ownerType := DB.NewScope(owner).TableName()
+	for _, t := range f {
+		sub := DB.Model(&Tag{}).Select("owner_id").Where("owner_type = ? AND tag_key = ?", ownerType, t.key)
+		if t.value != nil {
+			sub = sub.Where("value = ?", *t.value)
+		}
+		// the sub query is already enclosed in parentheses, another pair would turn it into a scalar sub query
+		q = q.Where("id IN ?", sub.SubQuery())
+	}
+	return q
+}
+
+//filterSensorsByTags keeps the sensors which have all tags required by the tag query parameters
+func filterSensorsByTags(c *gin.Context, sensors []Sensor) ([]Sensor, error) {
+	f, err := parseTagFilter(c)
+	if err != nil || len(f) == 0 || len(sensors) == 0 {
+		return sensors, err
+	}
+
+	ids := make([]uint, len(sensors))
+	for i := range sensors {
+		ids[i] = sensors[i].ID
+	}
+
+	var matches []uint
+	if err := f.apply(DB.Model(&Sensor{}).Where("id IN (?)", ids), &Sensor{}).Pluck("id", &matches).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[uint]bool, len(matches))
+	for _, id := range matches {
+		found[id] = true
+	}
+
+	r := make([]Sensor, 0, len(matches))
+	for i := range sensors {
+		if found[sensors[i].ID] {
+			r = append(r, sensors[i])
+		}
+	}
+	return r, nil
+}
+
+//replaceTags replaces all tags of the owner with the passed ones
+func replaceTags(tx *gorm.DB, owner interface{}, ownerID uint, tags []Tag) error {
+	ownerType := tx.NewScope(owner).TableName()
+	if err := deleteTags(tx, owner, []uint{ownerID}); err != nil {
+		return err
+	}
+
+	for _, t := range tags {
+		t.ID, t.OwnerID, t.OwnerType = 0, ownerID, ownerType
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//deleteTags deletes all tags of the owners with the passed ids
+func deleteTags(tx *gorm.DB, owner interface{}, ownerIDs []uint) error {
+	if len(ownerIDs) == 0 {
+		return nil
+	}
+	return tx.Where("owner_type = ? AND owner_id IN (?)", tx.NewScope(owner).TableName(), ownerIDs).
+		Delete(&Tag{}).Error
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+//asUser sends a request with a bearer token for the subject, an empty subject uses the admin API key
+func asUser(r http.Handler, subject string, method string, url string, body string) *httptest.ResponseRecorder {
+	var b io.Reader
+	if body != "" {
+		b = strings.NewReader(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, b)
+	if subject == "" {
+		req.Header.Set("X-API-Key", testAPIKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+signHS256(testClaims(subject)))
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createTestUser(t *testing.T, r http.Handler, name string, role Role, models ...uint) User {
+	grants := make([]map[string]uint, len(models))
+	for i, id := range models {
+		grants[i] = map[string]uint{"room_model_id": id}
+	}
+	w := asUser(r, "", http.MethodPost, "/users", AsJSON(map[string]interface{}{"name": name, "role": role, "grants": grants}))
+	assert.Equal(t, 201, w.Code)
+
+	var u User
+	_ = json.Unmarshal(w.Body.Bytes(), &u)
+	return u
+}
+
+func TestAccess(t *testing.T) {
+	r, _ := setupAuthRouter(t)
+
+	w := asUser(r, "", http.MethodPost, "/models", "{\"name\":\"North\",\"type\":\"Office\",\"floors\":1}")
+	assert.Equal(t, 201, w.Code)
+	var m RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	defer asUser(r, "", http.MethodDelete, fmt.Sprintf("/models/%d", m.ID), "")
+
+	w = asUser(r, "", http.MethodPost, "/sensors", AsJSON(map[string]interface{}{"name": "Flow", "room_model_id": m.ID}))
+	assert.Equal(t, 201, w.Code)
+	var s Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	sensor := fmt.Sprintf("/sensors/%d", s.ID)
+
+	viewer := createTestUser(t, r, "viewer-north", RoleViewer, m.ID)
+	defer asUser(r, "", http.MethodDelete, fmt.Sprintf("/users/%d", viewer.ID), "")
+	technician := createTestUser(t, r, "technician-north", RoleTechnician, m.ID)
+	defer asUser(r, "", http.MethodDelete, fmt.Sprintf("/users/%d", technician.ID), "")
+
+	// list endpoints only return the granted models and their sensors
+	w = asUser(r, "viewer-north", http.MethodGet, "/models", "")
+	assert.Equal(t, 200, w.Code)
+	var models []RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &models)
+	assert.Equal(t, 1, len(models))
+	assert.Equal(t, m.ID, models[0].ID)
+
+	w = asUser(r, "viewer-north", http.MethodGet, "/sensors", "")
+	assert.Equal(t, 200, w.Code)
+	var sensors []Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &sensors)
+	assert.Equal(t, 1, len(sensors))
+	assert.Equal(t, s.ID, sensors[0].ID)
+
+	w = asUser(r, "viewer-north", http.MethodGet, "/me", "")
+	assert.Equal(t, 200, w.Code)
+	var me Me
+	_ = json.Unmarshal(w.Body.Bytes(), &me)
+	assert.Equal(t, RoleViewer, me.Role)
+	assert.Equal(t, []uint{m.ID}, me.RoomModelIDs)
+
+	w = asUser(r, "viewer-north", http.MethodGet, "/maintenance", "")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+
+	assert.Equal(t, 200, asUser(r, "viewer-north", http.MethodGet, sensor+"/data", "").Code)
+	assert.Equal(t, 200, asUser(r, "viewer-north", http.MethodGet, sensor+"/anomalies", "").Code)
+	assert.Equal(t, 403, asUser(r, "viewer-north", http.MethodGet, "/sensors/1/data", "").Code)
+	assert.Equal(t, 403, asUser(r, "viewer-north", http.MethodGet, "/sensors/1/anomalies", "").Code)
+	assert.Equal(t, 403, asUser(r, "viewer-north", http.MethodGet, "/models/1", "").Code)
+	assert.Equal(t, 403, asUser(r, "viewer-north", http.MethodPatch, sensor, "{\"lower_bound\":5}").Code)
+	// previewing anomalies changes nothing
+	assert.Equal(t, 200, asUser(r, "viewer-north", http.MethodPost, sensor+"/anomalies/preview", "{\"lower_bound\":5}").Code)
+	assert.Equal(t, 403, asUser(r, "viewer-north", http.MethodPost, "/sensors/1/anomalies/preview", "{\"lower_bound\":5}").Code)
+
+	// technicians may configure the sensors of the granted models only
+	assert.Equal(t, 200, asUser(r, "technician-north", http.MethodPatch, sensor, "{\"lower_bound\":5}").Code)
+	assert.Equal(t, 403, asUser(r, "technician-north", http.MethodPatch, "/sensors/1", "{\"lower_bound\":5}").Code)
+	assert.Equal(t, 403, asUser(r, "technician-north", http.MethodPatch, sensor, "{\"room_model_id\":1}").Code)
+	assert.Equal(t, 403, asUser(r, "technician-north", http.MethodDelete, sensor, "").Code)
+	assert.Equal(t, 403, asUser(r, "technician-north", http.MethodGet, "/users", "").Code)
+
+	assert.Equal(t, 403, asUser(r, "stranger", http.MethodGet, "/models", "").Code)
+}
+
+func TestUsers(t *testing.T) {
+	r, _ := setupAuthRouter(t)
+
+	u := createTestUser(t, r, "viewer-south", RoleViewer, 1, 1)
+	assert.Equal(t, 1, len(u.Grants))
+	url := fmt.Sprintf("/users/%d", u.ID)
+
+	w := asUser(r, "", http.MethodPost, "/users", "{\"name\":\"viewer-south\",\"role\":\"viewer\"}")
+	assert.Equal(t, 409, w.Code)
+	w = asUser(r, "", http.MethodPost, "/users", "{\"name\":\"owner\",\"role\":\"owner\"}")
+	assert.Equal(t, 400, w.Code)
+	w = asUser(r, "", http.MethodPost, "/users", "{\"name\":\"viewer-east\",\"role\":\"viewer\",\"grants\":[{\"room_model_id\":5}]}")
+	assert.Equal(t, 400, w.Code)
+
+	w = asUser(r, "", http.MethodPut, url, "{\"name\":\"viewer-south\",\"role\":\"technician\"}")
+	assert.Equal(t, 200, w.Code)
+	w = asUser(r, "", http.MethodGet, url, "")
+	assert.Equal(t, 200, w.Code)
+	_ = json.Unmarshal(w.Body.Bytes(), &u)
+	assert.Equal(t, RoleTechnician, u.Role)
+	assert.Equal(t, 0, len(u.Grants))
+
+	assert.Equal(t, 204, asUser(r, "", http.MethodDelete, url, "").Code)
+	assert.Equal(t, 404, asUser(r, "", http.MethodGet, url, "").Code)
+}
@@ -2,25 +2,61 @@ package api
 
 import (
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/vi-sense/vi-sense/app/api"
+	"github.com/vi-sense/vi-sense/app/generator"
 	. "github.com/vi-sense/vi-sense/app/model"
+	. "github.com/vi-sense/vi-sense/app/storage"
 )
 
 // This is a hack way to add test database for each case, as whole test will just share one database.
 // You can read TestWithoutAuth's comment to know how to not share database each case.
 func TestMain(m *testing.M) {
+	// tests which need authentication enable it themselves
+	_ = os.Setenv("AUTH_DISABLED", "true")
 	SetupTestDatabase()
-	LoadModels("../../../sample-data", []string{"berlin"}, 5)
+	dataPath := "../../../sample-data"
+	var generated string
+	if _, err := os.Stat(filepath.Join(dataPath, "sensors", "berlin")); err != nil {
+		// the sample-data submodule is not checked out
+		generated, _ = ioutil.TempDir("", "visense-sample-data")
+		if err := generator.Write(generated, "berlin", newFixtureModel()); err != nil {
+			panic(err)
+		}
+		dataPath = generated
+	}
+	LoadModels(dataPath, []string{"berlin"}, 5)
+	dir, _ := ioutil.TempDir("", "visense-files")
+	SetupStorage(dir)
 	exitVal := m.Run()
 	DeleteTestDatabase()
+	_ = os.RemoveAll(dir)
+	if generated != "" {
+		_ = os.RemoveAll(generated)
+	}
 	os.Exit(exitVal)
 }
 
+//newFixtureModel generates the Berlin model of the sample data with its flow and return temperature sensors, the
+//first readings of the flow temperature are the ones of the sample data which the tests expect
+func newFixtureModel() *RoomModel {
+	m := generator.Model(0, generator.DefaultOptions())
+	m.TimeZone = ""
+	m.Sensors = m.Sensors[:2]
+	for i, r := range [][2]float64{{58.85, 1569888000}, {59.50921, 1569888318}, {58.599918, 1569888631},
+		{58.553765, 1569888932}, {58.572021, 1569889233}} {
+		m.Sensors[0].Data[i] = Data{Value: r[0], Date: Date{Time: time.Unix(int64(r[1]), 0).UTC()}}
+	}
+	return m
+}
+
 func TestPingRoute(t *testing.T) {
 	r := SetupRouter()
 
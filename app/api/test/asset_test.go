@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func TestAssetWithSensors(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	a := "{\"name\":\"Boiler 1\",\"type\":\"boiler\",\"manufacturer\":\"Viessmann\",\"install_date\":\"2015-06-01T00:00:00Z\"}"
+	req, _ := http.NewRequest(http.MethodPost, "/models/1/assets", strings.NewReader(a))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var asset Asset
+	_ = json.Unmarshal(w.Body.Bytes(), &asset)
+	assert.NotEqual(t, uint(0), asset.ID)
+	assert.Equal(t, uint(1), asset.RoomModelID)
+	assert.Equal(t, 2015, asset.InstallDate.Year())
+
+	w = httptest.NewRecorder()
+	i := map[string]interface{}{"asset_id": asset.ID, "lower_bound": 59.0}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/assets/%d", asset.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var status AssetStatus
+	_ = json.Unmarshal(w.Body.Bytes(), &status)
+	assert.Equal(t, "Boiler 1", status.Asset.Name)
+	assert.Equal(t, 1, len(status.Sensors))
+	assert.Equal(t, uint(5), status.Sensors[0].Sensor.LatestData.ID)
+	assert.Equal(t, 1, len(status.Sensors[0].ActiveAnomalies))
+	assert.Equal(t, BelowLowerLimit, status.Sensors[0].ActiveAnomalies[0].Type)
+	assert.Equal(t, uint(3), status.Sensors[0].ActiveAnomalies[0].StartData.ID)
+
+	// every anomaly going on at the latest reading is returned
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"gradient_bound": 0.00001}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/assets/%d", asset.ID), nil)
+	r.ServeHTTP(w, req)
+	_ = json.Unmarshal(w.Body.Bytes(), &status)
+	assert.Equal(t, 2, len(status.Sensors[0].ActiveAnomalies))
+	assert.Equal(t, BelowLowerLimit, status.Sensors[0].ActiveAnomalies[0].Type)
+	assert.Equal(t, UpwardGradient, status.Sensors[0].ActiveAnomalies[1].Type)
+	assert.Equal(t, uint(5), status.Sensors[0].ActiveAnomalies[1].StartData.ID)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"gradient_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/assets/%d/anomalies", asset.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var sa []SensorAnomalies
+	_ = json.Unmarshal(w.Body.Bytes(), &sa)
+	assert.Equal(t, 1, len(sa))
+	assert.Equal(t, 2, sa[0].Summary.Count)
+	assert.True(t, sa[0].Summary.Active)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, fmt.Sprintf("/assets/%d", asset.ID),
+		strings.NewReader("{\"name\":\"Boiler 1\",\"type\":\"condensing boiler\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &asset)
+	assert.Equal(t, "condensing boiler", asset.Type)
+	assert.Nil(t, asset.InstallDate)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/assets/%d", asset.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	var s Sensor
+	DB.First(&s, 1)
+	assert.Nil(t, s.AssetID)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"lower_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestAssetInvalid(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/models/1/assets", strings.NewReader("{\"name\":\"Pump\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/models/9999/assets", strings.NewReader("{\"name\":\"Pump\",\"type\":\"pump\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	i := map[string]interface{}{"asset_id": 9999}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/assets/9999", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func queryAuditEntries(t *testing.T, r http.Handler, url string) []AuditEntry {
+	w := asUser(r, "", http.MethodGet, url, "")
+	assert.Equal(t, 200, w.Code)
+
+	var entries []AuditEntry
+	_ = json.Unmarshal(w.Body.Bytes(), &entries)
+	return entries
+}
+
+func TestAuditEntries(t *testing.T) {
+	r, _ := setupAuthRouter(t)
+
+	w := asUser(r, "", http.MethodPost, "/models", "{\"name\":\"Audit\",\"type\":\"Office\",\"floors\":1}")
+	assert.Equal(t, 201, w.Code)
+	var m RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+
+	w = asUser(r, "", http.MethodPost, "/sensors", AsJSON(map[string]interface{}{"name": "Flow", "room_model_id": m.ID,
+		"upper_bound": 70.0}))
+	assert.Equal(t, 201, w.Code)
+	var s Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	url := fmt.Sprintf("/sensors/%d", s.ID)
+
+	w = asUser(r, "alice", http.MethodPatch, url, "{\"upper_bound\":75}")
+	assert.Equal(t, 200, w.Code)
+	// rejected changes are not recorded
+	w = asUser(r, "alice", http.MethodPatch, url, "{\"upper_bound\":\"hot\"}")
+	assert.Equal(t, 400, w.Code)
+
+	entries := queryAuditEntries(t, r, fmt.Sprintf("/audit?entity=sensor&id=%d", s.ID))
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "alice", entries[0].Actor)
+	assert.Equal(t, AuditUpdate, entries[0].Action)
+	assert.Equal(t, http.MethodPatch, entries[0].Method)
+	assert.Equal(t, url, entries[0].Path)
+
+	var before, after Sensor
+	_ = json.Unmarshal([]byte(entries[0].Before), &before)
+	_ = json.Unmarshal([]byte(entries[0].After), &after)
+	assert.Equal(t, 70.0, *before.UpperBound)
+	assert.Equal(t, 75.0, *after.UpperBound)
+
+	assert.Equal(t, "dashboard", entries[1].Actor)
+	assert.Equal(t, AuditCreate, entries[1].Action)
+	assert.Equal(t, JSONText(""), entries[1].Before)
+
+	w = asUser(r, "", http.MethodDelete, fmt.Sprintf("/models/%d", m.ID), "")
+	assert.Equal(t, 204, w.Code)
+
+	entries = queryAuditEntries(t, r, fmt.Sprintf("/audit?entity=model&id=%d", m.ID))
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, AuditDelete, entries[0].Action)
+	assert.Equal(t, JSONText(""), entries[0].After)
+	assert.True(t, strings.Contains(string(entries[0].Before), "\"name\":\"Audit\""))
+
+	entries = queryAuditEntries(t, r, "/audit?actor=alice&sort=id&limit=1")
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "alice", entries[0].Actor)
+
+	technician := createTestUser(t, r, "technician-audit", RoleTechnician)
+	defer asUser(r, "", http.MethodDelete, fmt.Sprintf("/users/%d", technician.ID), "")
+	assert.Equal(t, 403, asUser(r, "technician-audit", http.MethodGet, "/audit", "").Code)
+}
+
+func TestAuditIngestSummary(t *testing.T) {
+	r := SetupRouter()
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 20, 21, 22)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	// only a summary of the readings is recorded
+	entries := queryAuditEntries(t, r, fmt.Sprintf("/audit?entity=sensor_data&id=%d", s.ID))
+	assert.Equal(t, 1, len(entries))
+	var summary IngestAudit
+	assert.NoError(t, json.Unmarshal([]byte(entries[0].After), &summary))
+	assert.Equal(t, IngestAudit{SensorID: s.ID, Count: 3, FirstDate: start, LastDate: start.Add(2 * time.Minute)}, summary)
+}
+
+func TestAuditEntriesInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	for _, url := range []string{"/audit?id=x", "/audit?start_date=yesterday", "/audit?sort=size", "/audit?limit=-1"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, url)
+	}
+}
+
+func TestAuditFailureRejectsChange(t *testing.T) {
+	r := SetupRouter()
+
+	var s Sensor
+	DB.First(&s)
+	assert.NoError(t, DB.Exec("ALTER TABLE audit_entries RENAME TO audit_entries_moved").Error)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/sensors/%d", s.ID), strings.NewReader("{\"name\":\"Unaudited\"}"))
+	r.ServeHTTP(w, req)
+	assert.NoError(t, DB.Exec("ALTER TABLE audit_entries_moved RENAME TO audit_entries").Error)
+	assert.Equal(t, 500, w.Code)
+
+	var current Sensor
+	DB.First(&current, s.ID)
+	assert.Equal(t, s.Name, current.Name)
+}
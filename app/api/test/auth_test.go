@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+)
+
+const testAPIKey = "0123456789abcdef0123456789abcdef"
+
+var testSecret = []byte("a secret with at least 32 bytes..")
+
+//setupAuthRouter creates a router which requires an API key or a token signed by the returned RSA key or testSecret;
+//the principals dashboard, alice and bob are admins
+func setupAuthRouter(t *testing.T) (*gin.Engine, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	set := map[string]interface{}{"keys": []map[string]interface{}{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())},
+		{"kty": "oct", "kid": "hmac", "alg": "HS256", "k": base64.RawURLEncoding.EncodeToString(testSecret)},
+	}}
+	f, err := ioutil.TempFile("", "jwks-*.json")
+	assert.NoError(t, err)
+	_, _ = f.WriteString(AsJSON(set))
+	_ = f.Close()
+	defer os.Remove(f.Name())
+
+	_ = os.Setenv("AUTH_DISABLED", "false")
+	_ = os.Setenv("API_KEYS", "dashboard:"+testAPIKey)
+	_ = os.Setenv("JWT_KEY_SET", f.Name())
+	_ = os.Setenv("JWT_ISSUER", "https://auth.example.com")
+	_ = os.Setenv("JWT_AUDIENCE", "vi-sense")
+	_ = os.Setenv("ADMIN_USERS", "dashboard, alice, bob")
+	defer func() {
+		for _, e := range []string{"API_KEYS", "JWT_KEY_SET", "JWT_ISSUER", "JWT_AUDIENCE", "ADMIN_USERS"} {
+			_ = os.Unsetenv(e)
+		}
+		_ = os.Setenv("AUTH_DISABLED", "true")
+	}()
+
+	return SetupRouter(), key
+}
+
+//testClaims returns valid claims for the subject
+func testClaims(subject string) map[string]interface{} {
+	return map[string]interface{}{"sub": subject, "iss": "https://auth.example.com", "aud": "vi-sense",
+		"exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func signRS256(key *rsa.PrivateKey, claims map[string]interface{}) string {
+	signed := encodeSegment(map[string]string{"alg": "RS256", "kid": "rsa"}) + "." + encodeSegment(claims)
+	h := sha256.Sum256([]byte(signed))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signHS256(claims map[string]interface{}) string {
+	signed := encodeSegment(map[string]string{"alg": "HS256", "kid": "hmac"}) + "." + encodeSegment(claims)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeSegment(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func authRequest(r http.Handler, url string, header string, value string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthentication(t *testing.T) {
+	r, key := setupAuthRouter(t)
+
+	w := authRequest(r, "/models", "", "")
+	assert.Equal(t, 401, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+
+	assert.Equal(t, 200, authRequest(r, "/ping", "", "").Code)
+	assert.Equal(t, 200, authRequest(r, "/models", "X-API-Key", testAPIKey).Code)
+	assert.Equal(t, 401, authRequest(r, "/models", "X-API-Key", "0123456789abcdef").Code)
+
+	assert.Equal(t, 200, authRequest(r, "/models", "Authorization", "Bearer "+signRS256(key, testClaims("alice"))).Code)
+	assert.Equal(t, 200, authRequest(r, "/models", "Authorization", "Bearer "+signHS256(testClaims("bob"))).Code)
+	assert.Equal(t, 200, authRequest(r, "/models?access_token="+signHS256(testClaims("bob")), "", "").Code)
+	assert.Equal(t, 401, authRequest(r, "/models", "Authorization", "Basic Ym9iOmJvYg==").Code)
+}
+
+func TestAccessTokenIsNotLogged(t *testing.T) {
+	log := &bytes.Buffer{}
+	writer := gin.DefaultWriter
+	gin.DefaultWriter = log
+	r, _ := setupAuthRouter(t)
+	gin.DefaultWriter = writer
+
+	token := signHS256(testClaims("bob"))
+	assert.Equal(t, 200, authRequest(r, "/models?limit=1&access_token="+token, "", "").Code)
+	assert.Contains(t, log.String(), "/models?access_token=REDACTED&limit=1")
+	assert.NotContains(t, log.String(), token)
+}
+
+func TestAuthenticationInvalidToken(t *testing.T) {
+	r, key := setupAuthRouter(t)
+
+	expired := testClaims("alice")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	audience := testClaims("alice")
+	audience["aud"] = "another-service"
+	issuer := testClaims("alice")
+	issuer["iss"] = "https://evil.example.com"
+	missingExpiry := testClaims("alice")
+	delete(missingExpiry, "exp")
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	valid := signRS256(key, testClaims("alice"))
+	tampered := valid[:len(valid)-4] + "AAAA"
+	if tampered == valid {
+		tampered = valid[:len(valid)-4] + "BBBB"
+	}
+
+	for name, token := range map[string]string{
+		"expired":        signRS256(key, expired),
+		"audience":       signRS256(key, audience),
+		"issuer":         signHS256(issuer),
+		"missing expiry": signHS256(missingExpiry),
+		"unknown key":    signRS256(other, testClaims("alice")),
+		"tampered":       tampered,
+		"malformed":      "not.a.token",
+		"alg none":       encodeSegment(map[string]string{"alg": "none"}) + "." + encodeSegment(testClaims("alice")) + ".",
+	} {
+		w := authRequest(r, "/models", "Authorization", "Bearer "+token)
+		assert.Equal(t, 401, w.Code, name)
+	}
+}
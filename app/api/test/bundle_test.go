@@ -0,0 +1,386 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func TestExportImportRoomModel(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"upper_bound": 60.0, "mesh_id": 7, "tags": []Tag{{Key: "system", Value: "DHW"}}}
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/export", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	bundle := w.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	assert.Nil(t, err)
+
+	names := make([]string, 0)
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"sensors/berlin/model.json", "sensors/berlin/s1.csv", "sensors/berlin/s2.csv",
+		"sensors/berlin/bundle.json"}, names)
+
+	f, _ := archive.File[1].Open()
+	csv, _ := ioutil.ReadAll(f)
+	assert.True(t, strings.HasPrefix(string(csv), "value,date\n58.85,1569888000\n59.50921,1569888318\n"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/import", "berlin.zip", bundle))
+	assert.Equal(t, 201, w.Code)
+
+	var imported RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &imported)
+	assert.NotEqual(t, uint(1), imported.ID)
+
+	var original RoomModel
+	DB.Preload("Tags").Preload("Sensors").Preload("Sensors.Tags").First(&original, 1)
+	assert.Equal(t, original.Name, imported.Name)
+	assert.Equal(t, original.Location, imported.Location)
+	assert.Equal(t, original.TimeZone, imported.TimeZone)
+	assert.Equal(t, len(original.Sensors), len(imported.Sensors))
+
+	for n := range original.Sensors {
+		o, c := original.Sensors[n], imported.Sensors[n]
+		assert.Equal(t, imported.ID, c.RoomModelID)
+		assert.Equal(t, o.Name, c.Name)
+		assert.Equal(t, o.MeasurementUnit, c.MeasurementUnit)
+		assert.Equal(t, o.MeshID, c.MeshID)
+		assert.Equal(t, o.UpperBound, c.UpperBound)
+		assert.Equal(t, o.LowerBound, c.LowerBound)
+		assert.Equal(t, len(o.Tags), len(c.Tags))
+
+		var od, cd []Data
+		DB.Where("sensor_id = ?", o.ID).Order("date").Find(&od)
+		DB.Where("sensor_id = ?", c.ID).Order("date").Find(&cd)
+		assert.Equal(t, len(od), len(cd))
+		for k := range od {
+			assert.Equal(t, od[k].Value, cd[k].Value)
+			assert.Equal(t, od[k].Gradient, cd[k].Gradient)
+			assert.True(t, od[k].Date.Equal(cd[k].Date.Time))
+		}
+	}
+	assert.Equal(t, "DHW", imported.Sensors[0].Tags[0].Value)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/models/%d", imported.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"upper_bound": nil, "mesh_id": nil, "tags": []Tag{}}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestImportRoomModelInvalid(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/import", "berlin.zip", []byte("no zip")))
+	assert.Equal(t, 400, w.Code)
+
+	// the data file referenced by the model is missing
+	b := &bytes.Buffer{}
+	z := zip.NewWriter(b)
+	f, _ := z.Create("sensors/test/model.json")
+	_, _ = f.Write([]byte("{\"name\":\"Test\",\"type\":\"Office\",\"floors\":1,\"sensors\":[{\"import_name\":\"s1.csv\"}]}"))
+	_ = z.Close()
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/import", "test.zip", b.Bytes()))
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/models/9999/export", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+//newBundle creates a zip archive with the model.json and the csv files of its sensors in the folder sensors/test
+func newBundle(model string, csv ...string) []byte {
+	b := &bytes.Buffer{}
+	z := zip.NewWriter(b)
+	f, _ := z.Create("sensors/test/model.json")
+	_, _ = f.Write([]byte(model))
+	for n := range csv {
+		f, _ = z.Create(fmt.Sprintf("sensors/test/s%d.csv", n+1))
+		_, _ = f.Write([]byte(csv[n]))
+	}
+	_ = z.Close()
+	return b.Bytes()
+}
+
+func TestImportRoomModelIgnoresIDs(t *testing.T) {
+	r := SetupRouter()
+	bundle := newBundle("{\"id\":1,\"name\":\"Test\",\"type\":\"Office\",\"floors\":1,\"url\":\"/models/1/files/3\","+
+		"\"image_url\":\"/files/test/preview.png\",\"sensors\":[{\"id\":1,\"room_model_id\":1,\"asset_id\":5,"+
+		"\"room_id\":3,\"import_name\":\"s1.csv\",\"name\":\"Flow\"}]}", "value,date\n58.85,1569888000\n")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/import", "test.zip", bundle))
+	assert.Equal(t, 201, w.Code)
+
+	var imported RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &imported)
+	assert.NotEqual(t, uint(1), imported.ID)
+	assert.Equal(t, "", imported.Url)
+	assert.Equal(t, "/files/test/preview.png", imported.ImageUrl)
+	assert.Equal(t, 1, len(imported.Sensors))
+	assert.NotEqual(t, uint(1), imported.Sensors[0].ID)
+	assert.Nil(t, imported.Sensors[0].AssetID)
+	assert.Nil(t, imported.Sensors[0].RoomID)
+
+	var s Sensor
+	DB.First(&s, 1)
+	assert.Equal(t, uint(1), s.RoomModelID)
+	assert.Equal(t, "Flow Temperature", s.Name)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/models/%d", imported.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
+
+func TestExportRoomModelDropsFileUrls(t *testing.T) {
+	r := SetupRouter()
+	m := createTestModel(t, r)
+	url := fmt.Sprintf("/models/%d", m.ID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(url+"/files?kind=image", "preview.png", png))
+	assert.Equal(t, 201, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, url+"/export", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	bundle := w.Body.Bytes()
+	archive, _ := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	f, _ := archive.File[0].Open()
+	var exported map[string]interface{}
+	_ = json.NewDecoder(f).Decode(&exported)
+	assert.Equal(t, "", exported["image_url"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
+
+func TestImportRoomModelTooLarge(t *testing.T) {
+	_ = os.Setenv("MAX_IMPORT_DATA_SIZE", "1024")
+	r := SetupRouter()
+	_ = os.Unsetenv("MAX_IMPORT_DATA_SIZE")
+
+	// the data file compresses well but exceeds the limit once decompressed
+	bundle := newBundle("{\"name\":\"Test\",\"type\":\"Office\",\"floors\":1,\"sensors\":[{\"import_name\":\"s1.csv\"}]}",
+		"value,date\n"+strings.Repeat("58.85,1569888000\n", 1000))
+	assert.True(t, len(bundle) < 1024)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/import", "test.zip", bundle))
+	assert.Equal(t, 413, w.Code)
+
+	var count int
+	DB.Model(&RoomModel{}).Where("name = ?", "Test").Count(&count)
+	assert.Equal(t, 0, count)
+}
+
+//modelRecords contains all records of a model with the ids replaced by the names of the referenced records, so the
+//records of an exported and an imported model can be compared
+type modelRecords struct {
+	Model     RoomModel
+	Floors    []Floor
+	Assets    []Asset
+	Sensors   []Sensor
+	Rooms     []string
+	Schedules [][]BoundSchedule
+	Configs   [][]SensorConfig
+	Windows   []MaintenanceWindow
+	Files     []ModelFile
+	Data      [][]float64
+}
+
+func findModelRecords(id uint) modelRecords {
+	var r modelRecords
+	DB.Preload("Tags").Preload("Sensors").Preload("Sensors.Tags").First(&r.Model, id)
+	DB.Preload("Rooms").Where("room_model_id = ?", id).Order("id").Find(&r.Floors)
+	DB.Where("room_model_id = ?", id).Order("id").Find(&r.Assets)
+	DB.Where("room_model_id = ?", id).Order("id").Find(&r.Files)
+
+	sensors := map[uint]int{}
+	r.Sensors, r.Model.Sensors = r.Model.Sensors, nil
+	for i := range r.Sensors {
+		s := &r.Sensors[i]
+		sensors[s.ID] = i
+
+		var schedules []BoundSchedule
+		var configs []SensorConfig
+		var data []Data
+		DB.Where("sensor_id = ?", s.ID).Order("id").Find(&schedules)
+		DB.Where("sensor_id = ?", s.ID).Order("id").Find(&configs)
+		DB.Where("sensor_id = ?", s.ID).Order("date").Find(&data)
+		for j := range schedules {
+			schedules[j].ID, schedules[j].SensorID = 0, 0
+		}
+		for j := range configs {
+			configs[j].ID, configs[j].SensorID = 0, 0
+		}
+		values := make([]float64, len(data))
+		for j := range data {
+			values[j] = data[j].Value
+		}
+		r.Schedules, r.Configs, r.Data = append(r.Schedules, schedules), append(r.Configs, configs), append(r.Data, values)
+
+		room := ""
+		if s.RoomID != nil {
+			var m Room
+			DB.First(&m, *s.RoomID)
+			room = m.Name
+		}
+		if s.AssetID != nil {
+			var a Asset
+			DB.First(&a, *s.AssetID)
+			room += "/" + a.Name
+		}
+		r.Rooms = append(r.Rooms, room)
+		s.ID, s.RoomModelID, s.RoomID, s.AssetID = 0, 0, nil, nil
+		for j := range s.Tags {
+			s.Tags[j].ID, s.Tags[j].OwnerID = 0, 0
+		}
+	}
+
+	DB.Where("room_model_id = ?", id).Or("sensor_id IN (?)", keys(sensors)).Order("id").Find(&r.Windows)
+	for i := range r.Windows {
+		w := &r.Windows[i]
+		w.ID = 0
+		if w.RoomModelID != nil {
+			w.RoomModelID = new(uint)
+		} else {
+			sensor := uint(sensors[*w.SensorID])
+			w.SensorID = &sensor
+		}
+	}
+
+	for i := range r.Floors {
+		r.Floors[i].ID, r.Floors[i].RoomModelID = 0, 0
+		for j := range r.Floors[i].Rooms {
+			r.Floors[i].Rooms[j].ID, r.Floors[i].Rooms[j].FloorID = 0, 0
+		}
+	}
+	for i := range r.Assets {
+		r.Assets[i].ID, r.Assets[i].RoomModelID = 0, 0
+	}
+	for i := range r.Files {
+		f := &r.Files[i]
+		if fmt.Sprintf("/models/%d/files/%d", id, f.ID) == r.Model.Url {
+			r.Model.Url = "current"
+		}
+		f.ID, f.RoomModelID, f.Key, f.CreatedAt = 0, 0, "", time.Time{}
+	}
+	r.Model.ID = 0
+	for j := range r.Model.Tags {
+		r.Model.Tags[j].ID, r.Model.Tags[j].OwnerID = 0, 0
+	}
+	return r
+}
+
+func keys(m map[uint]int) []uint {
+	r := make([]uint, 0, len(m))
+	for k := range m {
+		r = append(r, k)
+	}
+	return r
+}
+
+func TestExportImportRoomModelRecords(t *testing.T) {
+	r := SetupRouter()
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+	model, sensor := fmt.Sprintf("/models/%d", m.ID), fmt.Sprintf("/sensors/%d", s.ID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(model+"/files?kind=model", "building.glb", glb))
+	assert.Equal(t, 201, w.Code)
+	w = asUser(r, "", http.MethodPost, sensor+"/data",
+		newReadings(time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC), 12, 12, 9, 12))
+	assert.Equal(t, 201, w.Code)
+
+	w = asUser(r, "", http.MethodPost, model+"/floors", "{\"name\":\"Ground\",\"level\":0}")
+	assert.Equal(t, 201, w.Code)
+	var f Floor
+	_ = json.Unmarshal(w.Body.Bytes(), &f)
+	w = asUser(r, "", http.MethodPost, fmt.Sprintf("/floors/%d/rooms", f.ID), "{\"name\":\"Boiler room\"}")
+	assert.Equal(t, 201, w.Code)
+	var room Room
+	_ = json.Unmarshal(w.Body.Bytes(), &room)
+	w = asUser(r, "", http.MethodPost, model+"/assets", "{\"name\":\"Boiler\",\"type\":\"boiler\",\"mesh_id\":1}")
+	assert.Equal(t, 201, w.Code)
+	var asset Asset
+	_ = json.Unmarshal(w.Body.Bytes(), &asset)
+
+	w = asUser(r, "", http.MethodPatch, sensor+"?valid_from=2019-10-01%2000:02:00", AsJSON(map[string]interface{}{
+		"room_id": room.ID, "asset_id": asset.ID, "mesh_id": 1, "lower_bound": 13}))
+	assert.Equal(t, 200, w.Code)
+	w = asUser(r, "", http.MethodPost, sensor+"/schedules",
+		"{\"name\":\"Night\",\"weekdays\":\"mon\",\"start_time\":\"22:00\",\"end_time\":\"06:00\",\"lower_bound\":5}")
+	assert.Equal(t, 201, w.Code)
+	for _, mw := range []map[string]interface{}{
+		{"room_model_id": m.ID, "start_date": "2019-10-01T00:00:00Z", "end_date": "2019-10-01T00:01:00Z"},
+		{"sensor_id": s.ID, "description": "service", "start_date": "2019-09-30T00:10:00Z",
+			"end_date": "2019-09-30T00:20:00Z", "recurrence": "weekly"},
+	} {
+		assert.Equal(t, 201, asUser(r, "", http.MethodPost, "/maintenance", AsJSON(mw)).Code)
+	}
+
+	w = asUser(r, "", http.MethodGet, model+"/export", "")
+	assert.Equal(t, 200, w.Code)
+	bundle := w.Body.Bytes()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/import", "upload.zip", bundle))
+	assert.Equal(t, 201, w.Code)
+	var imported RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &imported)
+	defer deleteTestModel(r, imported)
+
+	original, copied := findModelRecords(m.ID), findModelRecords(imported.ID)
+	assert.Equal(t, "current", original.Model.Url)
+	assert.Equal(t, 1, len(original.Floors))
+	assert.Equal(t, 1, len(original.Assets))
+	assert.Equal(t, 2, len(original.Configs[0]))
+	assert.Equal(t, 2, len(original.Windows))
+	assert.Equal(t, original.Model, copied.Model)
+	assert.Equal(t, original.Floors, copied.Floors)
+	assert.Equal(t, original.Assets, copied.Assets)
+	assert.Equal(t, original.Sensors, copied.Sensors)
+	assert.Equal(t, original.Rooms, copied.Rooms)
+	assert.Equal(t, original.Schedules, copied.Schedules)
+	assert.Equal(t, original.Configs, copied.Configs)
+	assert.Equal(t, original.Windows, copied.Windows)
+	assert.Equal(t, original.Files, copied.Files)
+	assert.Equal(t, original.Data, copied.Data)
+}
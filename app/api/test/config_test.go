@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vi-sense/vi-sense/app/config"
+)
+
+func TestConfig(t *testing.T) {
+	f, _ := ioutil.TempFile("", "visense-config")
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("# database\nPOSTGRES_HOST=db.example.com\nPOSTGRES_PORT=5433\nPOSTGRES_PASSWORD=\"secret\"\n" +
+		"API_KEYS=\nCORS_ALLOW_ORIGINS=https://a.example.com, https://b.example.com\n")
+	_ = f.Close()
+
+	_ = os.Setenv("POSTGRES_PORT", "5434")
+	_ = os.Setenv("POSTGRES_SSLMODE", "")
+	defer os.Unsetenv("POSTGRES_PORT")
+	defer os.Unsetenv("POSTGRES_SSLMODE")
+
+	c, err := config.Load([]string{"-config", f.Name(), "-postgres-user", "visense", "-production"})
+	assert.Nil(t, err)
+	assert.Equal(t, "db.example.com", c.Database.Host)
+	assert.Equal(t, 5434, c.Database.Port)
+	assert.Equal(t, "visense", c.Database.User)
+	assert.Equal(t, "secret", c.Database.Password)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, c.CORS.AllowOrigins)
+	assert.True(t, c.Production)
+	// empty settings are cleared, unset settings keep their defaults
+	assert.Equal(t, "", c.Database.SSLMode)
+	assert.Equal(t, 100, c.Throttle.Rate)
+	assert.Equal(t, "/uploads", c.Storage.Dir)
+
+	var dump bytes.Buffer
+	c.Dump(&dump)
+	assert.True(t, strings.Contains(dump.String(), "POSTGRES_PASSWORD=***\n"))
+	assert.True(t, strings.Contains(dump.String(), "API_KEYS=\n"))
+	assert.True(t, strings.Contains(dump.String(), "POSTGRES_USER=visense\n"))
+	assert.False(t, strings.Contains(dump.String(), "secret"))
+}
+
+func TestConfigInvalid(t *testing.T) {
+	f, _ := ioutil.TempFile("", "visense-config")
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("POSTGRES_HOST=db.example.com\nPOSTGRES_HOTS=db.example.com\n")
+	_ = f.Close()
+
+	for _, args := range [][]string{
+		{"-config", f.Name()},
+		{"-config", f.Name() + ".missing"},
+		{"-postgres-port", "x"},
+		{"-port", "0"},
+		{"-scheme", "ftp"},
+		{"-throttle-burst", "-1"},
+		{"-cors-allow-origins", " , "},
+		{"-https-addr", ":44344"},
+		{"-https-addr", ":44344", "-tls-cert-file", "c.pem", "-tls-key-file", "k.pem", "-tls-min-version", "1.4"},
+		{"-http-redirect"},
+		{"-https-addr", ":44344", "-tls-cert-file", "c.pem", "-tls-key-file", "k.pem", "-http-addr", "",
+			"-http-fallback"},
+		{"-http-write-timeout", "10"},
+		{"-http-addr", ""},
+		{"-unknown"},
+		{"-auth-disabled=false"},
+		{"-api-keys", "dashboard:0123456789abcdef"},
+	} {
+		_, err := config.Load(args)
+		assert.NotNil(t, err, args)
+	}
+
+	// an empty number is no valid override
+	_ = os.Setenv("THROTTLE_RATE", "")
+	defer os.Unsetenv("THROTTLE_RATE")
+	_, err := config.Load(nil)
+	assert.NotNil(t, err)
+}
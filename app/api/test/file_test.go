@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+	. "github.com/vi-sense/vi-sense/app/storage"
+)
+
+var glb = newGLB("{\"asset\":{\"version\":\"2.0\"},\"nodes\":[{\"name\":\"Root\"},{\"name\":\"Boiler\",\"mesh\":0}]," +
+	"\"meshes\":[{\"name\":\"Cylinder\"}]}")
+var png = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{1}, 32)...)
+
+//newGLB creates a binary glTF file which contains the passed JSON document only
+func newGLB(document string) []byte {
+	for len(document)%4 != 0 {
+		document += " "
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString("glTF")
+	_ = binary.Write(b, binary.LittleEndian, []uint32{2, uint32(12 + 8 + len(document)), uint32(len(document)), 0x4E4F534A})
+	b.WriteString(document)
+	return b.Bytes()
+}
+
+func newUploadRequest(url string, name string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, _ := w.CreateFormFile("file", name)
+	_, _ = part.Write(content)
+	_ = w.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, url, body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func createTestModel(t *testing.T, r http.Handler) RoomModel {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/models", strings.NewReader("{\"name\":\"Upload\",\"type\":\"Office\",\"floors\":1}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var m RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	return m
+}
+
+func TestUploadModelFile(t *testing.T) {
+	r := SetupRouter()
+	m := createTestModel(t, r)
+	url := fmt.Sprintf("/models/%d", m.ID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(url+"/files?kind=model", "building.glb", glb))
+	assert.Equal(t, 201, w.Code)
+
+	var f ModelFile
+	_ = json.Unmarshal(w.Body.Bytes(), &f)
+	assert.Equal(t, ModelFileKind, f.Kind)
+	assert.Equal(t, "model/gltf-binary", f.ContentType)
+	assert.Equal(t, int64(len(glb)), f.Size)
+	assert.Equal(t, 64, len(f.Checksum))
+	assert.Equal(t, fmt.Sprintf("%s/files/%d", url, f.ID), f.Url)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(url+"/files?kind=image", "preview.png", png))
+	assert.Equal(t, 201, w.Code)
+
+	var i ModelFile
+	_ = json.Unmarshal(w.Body.Bytes(), &i)
+	assert.Equal(t, "image/png", i.ContentType)
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	r.ServeHTTP(w, req)
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, f.Url, m.Url)
+	assert.Equal(t, i.Url, m.ImageUrl)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, f.Url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, glb, w.Body.Bytes())
+	assert.Equal(t, "model/gltf-binary", w.Header().Get("Content-Type"))
+	assert.Equal(t, fmt.Sprintf("\"%s\"", f.Checksum), w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url+"/files", nil)
+	r.ServeHTTP(w, req)
+	var l []ModelFile
+	_ = json.Unmarshal(w.Body.Bytes(), &l)
+	assert.Equal(t, 2, len(l))
+
+	var stored ModelFile
+	DB.First(&stored, f.ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, f.Url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+	_, err := Files.Open(stored.Key)
+	assert.NotNil(t, err)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	r.ServeHTTP(w, req)
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, "", m.Url)
+	assert.Equal(t, i.Url, m.ImageUrl)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, f.Url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	var count int
+	DB.Model(&ModelFile{}).Where("room_model_id = ?", m.ID).Count(&count)
+	assert.Equal(t, 0, count)
+}
+
+func TestUploadModelFileInvalid(t *testing.T) {
+	_ = os.Setenv("MAX_IMAGE_FILE_SIZE", "16")
+	r := SetupRouter()
+	_ = os.Unsetenv("MAX_IMAGE_FILE_SIZE")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/models/1/files?kind=image", "preview.png", png))
+	assert.Equal(t, 413, w.Code)
+
+	// the body is rejected while it is read
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/models/1/files?kind=image", "preview.png", bytes.Repeat(png, 1<<16)))
+	assert.Equal(t, 413, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/models/1/files?kind=image", "preview.png", []byte("no image")))
+	assert.Equal(t, 415, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/models/1/files?kind=model", "building.gltf", []byte("{\"scenes\":[]}")))
+	assert.Equal(t, 415, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/models/1/files?kind=video", "building.glb", glb))
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest("/models/1000/files?kind=model", "building.glb", glb))
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestUploadModelFileRollback(t *testing.T) {
+	r := SetupRouter()
+	m := createTestModel(t, r)
+	defer deleteTestModel(r, m)
+
+	content := append(png, []byte("rollback")...)
+	sum := sha256.Sum256(content)
+	key := fmt.Sprintf("%d/%s.png", m.ID, hex.EncodeToString(sum[:]))
+
+	// the stored content is removed again if the upload can not be recorded
+	assert.NoError(t, DB.Exec("ALTER TABLE audit_entries RENAME TO audit_entries_moved").Error)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(fmt.Sprintf("/models/%d/files?kind=image", m.ID), "preview.png", content))
+	assert.NoError(t, DB.Exec("ALTER TABLE audit_entries_moved RENAME TO audit_entries").Error)
+	assert.Equal(t, 500, w.Code)
+
+	_, err := Files.Open(key)
+	assert.NotNil(t, err)
+}
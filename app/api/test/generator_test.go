@@ -0,0 +1,93 @@
+package api
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	"github.com/vi-sense/vi-sense/app/generator"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func TestGenerateSeries(t *testing.T) {
+	o := generator.DefaultOptions()
+	o.Duration = 24 * time.Hour
+	o.SpikeRate, o.FlatlineRate, o.GapRate = 0.02, 0.01, 0.01
+	o.FlatlineLength, o.GapLength = 6, 6
+
+	m := generator.Model(0, o)
+	assert.Equal(t, "Berlin", m.Name)
+	assert.NoError(t, m.Validate())
+	assert.Equal(t, len(generator.HeatingSystem), len(m.Sensors))
+	assert.Equal(t, m.Sensors[0].Data, generator.Model(0, o).Sensors[0].Data)
+
+	var gaps, flatlines, spikes int
+	for _, s := range m.Sensors {
+		series := generator.HeatingSystem[0]
+		for _, h := range generator.HeatingSystem {
+			if h.Name == s.Name {
+				series = h
+			}
+		}
+
+		run := 0
+		for i := 1; i < len(s.Data); i++ {
+			d, p := s.Data[i], s.Data[i-1]
+			assert.True(t, d.Date.After(p.Date.Time))
+			assert.Equal(t, Gradient(&p, &d), d.Gradient)
+
+			if d.Date.Sub(p.Date.Time) > 2*o.Interval {
+				gaps++
+			}
+			if d.Value == p.Value {
+				run++
+			} else {
+				run = 0
+			}
+			if run == o.FlatlineLength-1 {
+				flatlines++
+			}
+			if math.Abs(d.Value-p.Value) > 2*series.Amplitude {
+				spikes++
+			}
+		}
+	}
+	assert.True(t, gaps > 0)
+	assert.True(t, flatlines > 0)
+	assert.True(t, spikes > 0)
+}
+
+func TestGenerateLoadModels(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "visense-generated")
+	defer os.RemoveAll(dir)
+
+	o := generator.DefaultOptions()
+	o.Duration = 24 * time.Hour
+	m := generator.Model(1, o)
+	assert.NoError(t, generator.Write(dir, FolderName(m.Name), m))
+
+	LoadModels(dir, []string{"cape-town"}, -1)
+
+	var loaded RoomModel
+	DB.Preload("Sensors").Where("name = ?", "Cape Town").Last(&loaded)
+	assert.NotEqual(t, uint(0), loaded.ID)
+	assert.Equal(t, "Africa/Johannesburg", loaded.TimeZone)
+	assert.Equal(t, len(m.Sensors), len(loaded.Sensors))
+
+	var count int
+	DB.Model(&Data{}).Where("sensor_id = ?", loaded.Sensors[0].ID).Count(&count)
+	assert.Equal(t, len(m.Sensors[0].Data), count)
+
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/models/"+strconv.Itoa(int(loaded.ID)), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func TestFloorsAndRooms(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/models/1/floors", strings.NewReader("{\"name\":\"Basement\",\"level\":-1}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var f Floor
+	_ = json.Unmarshal(w.Body.Bytes(), &f)
+	assert.Equal(t, uint(1), f.RoomModelID)
+	assert.Equal(t, -1, f.Level)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/floors/%d/rooms", f.ID),
+		strings.NewReader("{\"name\":\"Boiler room\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var room Room
+	_ = json.Unmarshal(w.Body.Bytes(), &room)
+	assert.Equal(t, f.ID, room.FloorID)
+	assert.Equal(t, RoomKindRoom, room.Kind)
+
+	w = httptest.NewRecorder()
+	i := map[string]interface{}{"room_id": room.ID, "lower_bound": 59.0}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/floors", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var floors []Floor
+	_ = json.Unmarshal(w.Body.Bytes(), &floors)
+	assert.Equal(t, 1, len(floors))
+	assert.Equal(t, 1, len(floors[0].Rooms))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/rooms/%d", room.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &room)
+	assert.Equal(t, 1, len(room.Sensors))
+	assert.Equal(t, uint(1), room.Sensors[0].ID)
+	assert.Equal(t, uint(5), room.Sensors[0].LatestData.ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/floors/%d/aggregates", f.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var a []Aggregate
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 1, len(a))
+	assert.Equal(t, 1, a[0].Sensors)
+	assert.Equal(t, 5, a[0].Count)
+	assert.Equal(t, 58.553765, a[0].Min)
+	assert.Equal(t, 59.50921, a[0].Max)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet,
+		fmt.Sprintf("/rooms/%d/aggregates?start_date=2019-10-01+00:10:00", room.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 3, a[0].Count)
+	assert.Equal(t, 58.599918, a[0].Max)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/floors/%d/anomalies", f.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var sa []SensorAnomalies
+	_ = json.Unmarshal(w.Body.Bytes(), &sa)
+	assert.Equal(t, 1, len(sa))
+	assert.Equal(t, uint(1), sa[0].SensorID)
+	assert.Equal(t, 2, sa[0].Summary.Count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/floors/%d", f.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/rooms/%d", room.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	var s Sensor
+	DB.First(&s, 1)
+	assert.Nil(t, s.RoomID)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"lower_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestFloorsAndRoomsInvalid(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/models/1/floors", strings.NewReader("{\"level\":0}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/models/1/floors", strings.NewReader("{\"name\":\"Ground floor\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var f Floor
+	_ = json.Unmarshal(w.Body.Bytes(), &f)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/floors/%d/rooms", f.ID),
+		strings.NewReader("{\"name\":\"Hall\",\"kind\":\"corridor\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	i := map[string]interface{}{"room_id": 9999}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/floors/9999/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/floors/%d", f.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
+
+func TestUpdateFloorsAndRooms(t *testing.T) {
+	r := SetupRouter()
+	m := createTestModel(t, r)
+
+	floors := make([]Floor, 2)
+	for i := range floors {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/models/%d/floors", m.ID),
+			strings.NewReader(fmt.Sprintf("{\"name\":\"Floor %d\",\"level\":%d}", i, i)))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 201, w.Code)
+		_ = json.Unmarshal(w.Body.Bytes(), &floors[i])
+	}
+
+	var q RoomModel
+	DB.First(&q, m.ID)
+	assert.Equal(t, 2, q.Floors)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/floors/%d", floors[0].ID),
+		strings.NewReader("{\"name\":\"Ground floor\",\"level\":0,\"room_model_id\":1}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var f Floor
+	_ = json.Unmarshal(w.Body.Bytes(), &f)
+	assert.Equal(t, "Ground floor", f.Name)
+	assert.Equal(t, m.ID, f.RoomModelID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/floors/%d/rooms", f.ID),
+		strings.NewReader("{\"name\":\"Lab\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var room Room
+	_ = json.Unmarshal(w.Body.Bytes(), &room)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, fmt.Sprintf("/rooms/%d", room.ID),
+		strings.NewReader(fmt.Sprintf("{\"name\":\"Open space\",\"kind\":\"zone\",\"floor_id\":%d}", floors[1].ID)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &room)
+	assert.Equal(t, "Open space", room.Name)
+	assert.Equal(t, RoomKindZone, room.Kind)
+	assert.Equal(t, floors[1].ID, room.FloorID)
+
+	for _, body := range []string{"{\"name\":\"\"}", "{\"name\":\"Lab\",\"floor_id\":9999}",
+		"{\"name\":\"Lab\",\"kind\":\"corridor\"}"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodPut, fmt.Sprintf("/rooms/%d", room.ID), strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, body)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, fmt.Sprintf("/models/%d", m.ID), strings.NewReader("{\"floors\":5}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/floors/%d", floors[1].ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	DB.First(&q, m.ID)
+	assert.Equal(t, 1, q.Floors)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/floors/9999", strings.NewReader("{\"name\":\"Roof\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/models/%d", m.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
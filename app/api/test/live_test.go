@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"golang.org/x/net/websocket"
+)
+
+//createTestSensor creates a sensor with a lower bound in a new model, deleting the model removes both
+func createTestSensor(t *testing.T, r http.Handler) (RoomModel, Sensor) {
+	m := createTestModel(t, r)
+
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"name": "Live", "room_model_id": m.ID, "lower_bound": 10.0}
+	req, _ := http.NewRequest(http.MethodPost, "/sensors", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var s Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	return m, s
+}
+
+func deleteTestModel(r http.Handler, m RoomModel) {
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/models/%d", m.ID), nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func newReadings(start time.Time, values ...float64) string {
+	readings := make([]map[string]interface{}, len(values))
+	for i, v := range values {
+		readings[i] = map[string]interface{}{"value": v, "date": start.Add(time.Duration(i) * time.Minute)}
+	}
+	return AsJSON(readings)
+}
+
+func receiveEvent(t *testing.T, ws *websocket.Conn) Event {
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var e Event
+	assert.NoError(t, websocket.JSON.Receive(ws, &e))
+	return e
+}
+
+func TestIngestSensorData(t *testing.T) {
+	r := SetupRouter()
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 20, 26)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var data []Data
+	_ = json.Unmarshal(w.Body.Bytes(), &data)
+	assert.Equal(t, 2, len(data))
+	assert.NotEqual(t, uint(0), data[1].ID)
+	assert.Equal(t, 0.1, data[1].Gradient)
+
+	// readings have to be newer than the latest one
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 30)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	// readings less than a second apart would have no finite gradient
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(fmt.Sprintf("[{\"value\":30,\"date\":%q},{\"value\":31,\"date\":%q}]",
+			start.Add(time.Hour).Format(time.RFC3339Nano), start.Add(time.Hour+500*time.Millisecond).Format(time.RFC3339Nano))))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+	var count int
+	DB.Model(&Data{}).Where("sensor_id = ?", s.ID).Count(&count)
+	assert.Equal(t, 2, count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID), strings.NewReader("[{\"value\":1}]"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/sensors/1000/data", strings.NewReader(newReadings(start, 1)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestLiveUpdates(t *testing.T) {
+	r := SetupRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	url := strings.Replace(server.URL, "http", "ws", 1)
+	ws, err := websocket.Dial(fmt.Sprintf("%s/live?sensor_id=%d", url, s.ID), "", server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 20, 5, 6, 15)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	expected := []EventType{DataEvent, DataEvent, AnomalyStartEvent, DataEvent, DataEvent, AnomalyEndEvent}
+	var events []Event
+	for range expected {
+		events = append(events, receiveEvent(t, ws))
+	}
+	for i := range expected {
+		assert.Equal(t, expected[i], events[i].Type)
+		assert.Equal(t, s.ID, events[i].SensorID)
+		assert.Equal(t, m.ID, events[i].RoomModelID)
+	}
+	assert.Equal(t, 5.0, events[2].Anomaly.StartData.Value)
+	assert.Equal(t, BelowLowerLimit, events[5].Anomaly.Type)
+	assert.Equal(t, 6.0, events[5].Anomaly.EndData.Value)
+	assert.True(t, events[0].ID < events[5].ID)
+
+	// invalid requests are answered with an error event
+	assert.NoError(t, websocket.Message.Send(ws, "{\"action\":\"subscribe\",\"sensor_ids\":[100000]}"))
+	assert.Equal(t, ErrorEvent, receiveEvent(t, ws).Type)
+
+	// a new reading after unsubscribing is only received by the subscription of the model
+	assert.NoError(t, websocket.Message.Send(ws, fmt.Sprintf("{\"action\":\"subscribe\",\"room_model_ids\":[%d]}", m.ID)))
+	assert.NoError(t, websocket.Message.Send(ws, fmt.Sprintf("{\"action\":\"unsubscribe\",\"sensor_ids\":[%d]}", s.ID)))
+	assert.NoError(t, websocket.Message.Send(ws, "{\"action\":\"invalid\"}"))
+	assert.Equal(t, ErrorEvent, receiveEvent(t, ws).Type)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start.Add(time.Hour), 1)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	e := receiveEvent(t, ws)
+	assert.Equal(t, DataEvent, e.Type)
+	assert.Equal(t, 1.0, e.Data.Value)
+	e = receiveEvent(t, ws)
+	assert.Equal(t, AnomalyStartEvent, e.Type)
+}
+
+func TestLiveUpdatesLookback(t *testing.T) {
+	r := SetupRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 5)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	// discards the anomaly state, the next reading primes a new detector
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, fmt.Sprintf("/sensors/%d", s.ID), strings.NewReader("{\"name\":\"Lookback\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	url := strings.Replace(server.URL, "http", "ws", 1)
+	ws, err := websocket.Dial(fmt.Sprintf("%s/live?sensor_id=%d", url, s.ID), "", server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+
+	// the anomaly of the reading two days before is outside of the evaluated period
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start.Add(48*time.Hour), 4)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	assert.Equal(t, DataEvent, receiveEvent(t, ws).Type)
+	e := receiveEvent(t, ws)
+	assert.Equal(t, AnomalyStartEvent, e.Type)
+	assert.Equal(t, 4.0, e.Anomaly.StartData.Value)
+}
+
+func TestLiveUpdatesInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/live?room_model_id=1000", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/live?sensor_id=malformed", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
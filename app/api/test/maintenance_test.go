@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func TestMaintenanceWindowSuppressesAnomalies(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"lower_bound": 59.0}
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	mw := "{\"sensor_id\":1,\"description\":\"boiler service\"," +
+		"\"start_date\":\"2019-10-01T00:10:00Z\",\"end_date\":\"2019-10-01T00:16:00Z\"}"
+	req, _ = http.NewRequest(http.MethodPost, "/maintenance", strings.NewReader(mw))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var m MaintenanceWindow
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.NotEqual(t, uint(0), m.ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var a []Anomaly
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 2, len(a))
+	assert.Equal(t, uint(1), a[0].StartData.ID)
+	assert.Equal(t, uint(5), a[1].StartData.ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/anomalies?maintenance=tag", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 2, len(a))
+	assert.False(t, a[0].Maintenance)
+	assert.Equal(t, uint(3), a[1].StartData.ID)
+	assert.True(t, a[1].Maintenance)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/maintenance/%d", m.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"lower_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestMaintenanceWindowRecurring(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	mw := "{\"room_model_id\":1,\"start_date\":\"2019-09-30T00:14:00Z\",\"end_date\":\"2019-09-30T00:16:00Z\"," +
+		"\"recurrence\":\"daily\"}"
+	req, _ := http.NewRequest(http.MethodPost, "/maintenance", strings.NewReader(mw))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var m MaintenanceWindow
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+
+	w = httptest.NewRecorder()
+	i := map[string]interface{}{"upper_bound": 58.0}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	// the reading at 00:15:32 lies within the recurrence of the previous day
+	var a []Anomaly
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 2, len(a))
+	assert.Equal(t, uint(3), a[0].EndData.ID)
+	assert.Equal(t, uint(5), a[1].StartData.ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/maintenance?room_model_id=1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var l []MaintenanceWindow
+	_ = json.Unmarshal(w.Body.Bytes(), &l)
+	assert.Equal(t, 1, len(l))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/maintenance/%d", m.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"upper_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestUpcomingMaintenance(t *testing.T) {
+	r := SetupRouter()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	windows := []map[string]interface{}{
+		{"sensor_id": 1, "start_date": now.Add(3 * time.Hour), "end_date": now.Add(4 * time.Hour)},
+		{"sensor_id": 1, "start_date": now.Add(-49 * time.Hour), "end_date": now.Add(-47 * time.Hour),
+			"recurrence": "daily"},
+		{"sensor_id": 1, "start_date": now.Add(72 * time.Hour), "end_date": now.Add(73 * time.Hour)},
+		{"sensor_id": 1, "start_date": now.Add(-3 * time.Hour), "end_date": now.Add(-2 * time.Hour)},
+	}
+	ids := make([]uint, len(windows))
+	for i, mw := range windows {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/maintenance", strings.NewReader(AsJSON(mw)))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 201, w.Code)
+
+		var m MaintenanceWindow
+		_ = json.Unmarshal(w.Body.Bytes(), &m)
+		ids[i] = m.ID
+	}
+	defer func() {
+		for _, id := range ids {
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/maintenance/%d", id), nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}()
+
+	// the daily window is going on, the one-off window starts in three hours and the others are out of range
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/maintenance/upcoming?sensor_id=1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var o []MaintenanceOccurrence
+	_ = json.Unmarshal(w.Body.Bytes(), &o)
+	assert.Equal(t, 2, len(o))
+	assert.Equal(t, ids[1], o[0].Window.ID)
+	assert.True(t, now.Add(-time.Hour).Equal(o[0].StartDate))
+	assert.True(t, now.Add(time.Hour).Equal(o[0].EndDate))
+	assert.Equal(t, ids[0], o[1].Window.ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/maintenance/upcoming?sensor_id=1&hours=96", nil)
+	r.ServeHTTP(w, req)
+	_ = json.Unmarshal(w.Body.Bytes(), &o)
+	assert.Equal(t, 3, len(o))
+
+	for _, url := range []string{"/maintenance/upcoming?hours=0", "/maintenance/upcoming?hours=soon"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, url, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, url)
+	}
+}
+
+func TestMaintenanceWindowInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	windows := []string{
+		"{\"start_date\":\"2019-10-01T00:10:00Z\",\"end_date\":\"2019-10-01T00:16:00Z\"}",
+		"{\"sensor_id\":1,\"room_model_id\":1,\"start_date\":\"2019-10-01T00:10:00Z\",\"end_date\":\"2019-10-01T00:16:00Z\"}",
+		"{\"sensor_id\":1,\"start_date\":\"2019-10-01T00:10:00Z\",\"end_date\":\"2019-10-01T00:06:00Z\"}",
+		"{\"sensor_id\":1,\"start_date\":\"2019-10-01T00:10:00Z\",\"end_date\":\"2019-10-03T00:16:00Z\",\"recurrence\":\"daily\"}",
+		"{\"sensor_id\":1,\"start_date\":\"2019-10-01T00:10:00Z\",\"end_date\":\"2019-10-01T00:16:00Z\",\"recurrence\":\"hourly\"}",
+	}
+
+	for _, mw := range windows {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/maintenance", strings.NewReader(mw))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, mw)
+	}
+
+	w := httptest.NewRecorder()
+	mw := "{\"sensor_id\":1000,\"start_date\":\"2019-10-01T00:10:00Z\",\"end_date\":\"2019-10-01T00:16:00Z\"}"
+	req, _ := http.NewRequest(http.MethodPost, "/maintenance", strings.NewReader(mw))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/anomalies?maintenance=unknown", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
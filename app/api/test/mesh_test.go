@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	"github.com/vi-sense/vi-sense/app/gltf"
+)
+
+func TestQueryModelMeshes(t *testing.T) {
+	r := SetupRouter()
+	m := createTestModel(t, r)
+	url := fmt.Sprintf("/models/%d", m.ID)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, url+"/meshes", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	document := "{\"asset\":{\"version\":\"2.0\"},\"nodes\":[{\"name\":\"Root\",\"children\":[1,2]}," +
+		"{\"name\":\"Boiler\",\"mesh\":0},{\"name\":\"Pump\",\"mesh\":1}],\"meshes\":[{\"name\":\"Cylinder\"},{\"name\":\"Cube\"}]}"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(url+"/files?kind=model", "building.glb", newGLB(document)))
+	assert.Equal(t, 201, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url+"/meshes", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var meshes []gltf.Mesh
+	_ = json.Unmarshal(w.Body.Bytes(), &meshes)
+	assert.Equal(t, []gltf.Mesh{
+		{ID: 1, Name: "Boiler", MeshIndex: 0, MeshName: "Cylinder"},
+		{ID: 2, Name: "Pump", MeshIndex: 1, MeshName: "Cube"},
+	}, meshes)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
+
+func TestSensorMeshIDValidation(t *testing.T) {
+	r := SetupRouter()
+	m := createTestModel(t, r)
+	url := fmt.Sprintf("/models/%d", m.ID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(url+"/files?kind=model", "building.glb", glb))
+	assert.Equal(t, 201, w.Code)
+
+	w = httptest.NewRecorder()
+	i := map[string]interface{}{"room_model_id": m.ID, "name": "Boiler Temperature", "mesh_id": 0}
+	req, _ := http.NewRequest(http.MethodPost, "/sensors", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	i["mesh_id"] = 1
+	req, _ = http.NewRequest(http.MethodPost, "/sensors", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var s map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	sensor := fmt.Sprintf("/sensors/%.0f", s["id"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, sensor, strings.NewReader("{\"mesh_id\":357}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, sensor+"?force=true", strings.NewReader("{\"mesh_id\":357}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Header().Get("Warning"), "Mesh 357 does not exist")
+
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	assert.Equal(t, 357.0, s["mesh_id"])
+
+	// moving the sensor into the model checks its current mesh id as well
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader("{\"mesh_id\":357}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(fmt.Sprintf("{\"room_model_id\":%d}", m.ID)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader("{\"mesh_id\":null}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	"github.com/vi-sense/vi-sense/app/config"
+	"golang.org/x/net/websocket"
+)
+
+func startReplay(t *testing.T, r http.Handler, modelID uint, start time.Time, speed float64) Replay {
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"room_model_id": modelID, "start": start, "speed": speed}
+	req, _ := http.NewRequest(http.MethodPost, "/replays", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var replay Replay
+	_ = json.Unmarshal(w.Body.Bytes(), &replay)
+	return replay
+}
+
+func changeReplay(t *testing.T, r http.Handler, id uint, action string, code int) Replay {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/replays/%d/%s", id, action), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, code, w.Code)
+
+	var replay Replay
+	_ = json.Unmarshal(w.Body.Bytes(), &replay)
+	return replay
+}
+
+func TestReplay(t *testing.T) {
+	r := SetupRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 30, 20, 5, 6, 15)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	url := strings.Replace(server.URL, "http", "ws", 1)
+	ws, err := websocket.Dial(fmt.Sprintf("%s/live?room_model_id=%d", url, m.ID), "", server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ws.Close()
+
+	// a minute between two readings takes 10 milliseconds
+	replay := startReplay(t, r, m.ID, start.Add(time.Minute), 6000)
+	assert.Equal(t, ReplayRunning, replay.State)
+
+	expected := []EventType{DataEvent, DataEvent, AnomalyStartEvent, DataEvent, DataEvent, AnomalyEndEvent}
+	for i := range expected {
+		e := receiveEvent(t, ws)
+		assert.Equal(t, expected[i], e.Type)
+		assert.Equal(t, replay.ID, e.ReplayID)
+		if i == 0 {
+			assert.Equal(t, 20.0, e.Data.Value)
+		}
+	}
+
+	for i := 0; i < 100 && replay.State == ReplayRunning; i++ {
+		time.Sleep(10 * time.Millisecond)
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/replays/%d", replay.ID), nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		_ = json.Unmarshal(w.Body.Bytes(), &replay)
+	}
+	assert.Equal(t, ReplayFinished, replay.State)
+	assert.Equal(t, 4, replay.Count)
+	assert.Equal(t, start.Add(4*time.Minute), replay.Position.UTC())
+	changeReplay(t, r, replay.ID, "pause", 409)
+
+	// in real time the second reading follows after a minute
+	replay = startReplay(t, r, m.ID, start, 1)
+	assert.Equal(t, 30.0, receiveEvent(t, ws).Data.Value)
+
+	assert.Equal(t, ReplayPaused, changeReplay(t, r, replay.ID, "pause", 200).State)
+	changeReplay(t, r, replay.ID, "pause", 409)
+	assert.Equal(t, ReplayRunning, changeReplay(t, r, replay.ID, "resume", 200).State)
+	assert.Equal(t, ReplayStopped, changeReplay(t, r, replay.ID, "stop", 200).State)
+	changeReplay(t, r, replay.ID, "resume", 409)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/replays", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var all []Replay
+	_ = json.Unmarshal(w.Body.Bytes(), &all)
+	assert.True(t, len(all) >= 2)
+}
+
+func TestReplayPruning(t *testing.T) {
+	// the replays are started and stopped faster than the throttle accepts by default
+	c, _ := config.Load(nil)
+	c.Throttle.Burst = 1000
+	r := NewRouter(c)
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 30, 20)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	// only the latest 100 ended replays are kept
+	ids := make([]uint, 101)
+	for i := range ids {
+		replay := startReplay(t, r, m.ID, start, 1)
+		ids[i] = replay.ID
+		assert.Equal(t, ReplayStopped, changeReplay(t, r, replay.ID, "stop", 200).State)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/replays/%d", ids[0]), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/replays/%d", ids[1]), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/replays", nil)
+	r.ServeHTTP(w, req)
+	var all []Replay
+	_ = json.Unmarshal(w.Body.Bytes(), &all)
+	assert.Equal(t, 100, len(all))
+}
+
+func TestReplayInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	for _, body := range []string{"{\"room_model_id\":1}", "{\"room_model_id\":1,\"start\":\"2020-01-01T00:00:00Z\",\"speed\":-1}",
+		"malformed"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/replays", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/replays", strings.NewReader("{\"room_model_id\":1000,\"start\":\"2020-01-01T00:00:00Z\"}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/replays/1000", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
@@ -1,12 +1,16 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
 )
 
 func TestQueryRoomModels(t *testing.T) {
@@ -44,3 +48,255 @@ func TestQueryRoomModelIDMalformed(t *testing.T) {
 
 	assert.Equal(t, 404, w.Code)
 }
+
+func TestQueryModelAnomalies(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"upper_bound": 58.8, "lower_bound": 58.58}
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var a []SensorAnomalies
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, uint(1), a[0].SensorID)
+	assert.Equal(t, 2, a[0].Summary.Count)
+	assert.Equal(t, AboveUpperLimit, a[0].Summary.WorstType)
+	assert.Equal(t, 0.01206, a[0].Summary.WorstSeverity)
+	assert.True(t, a[0].Summary.Active)
+	assert.Equal(t, AboveUpperLimit, a[0].Anomalies[0].Type)
+	assert.Equal(t, BelowLowerLimit, a[0].Anomalies[1].Type)
+
+	for _, s := range a[1:] {
+		assert.Equal(t, 0, s.Summary.Count)
+		assert.Equal(t, 0, len(s.Anomalies))
+	}
+
+	// the anomaly is still going on at the end of the period but not at the sensor's latest reading
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/anomalies?end_date=2019-10-01 00:16:00", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 2, a[0].Summary.Count)
+	assert.False(t, a[0].Summary.Active)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"upper_bound": nil, "lower_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestQueryModelAnomaliesIDNotFound(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/models/5/anomalies", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestCreateUpdateDeleteRoomModel(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	b := "{\"name\":\"Cape Town\",\"type\":\"Residential\",\"floors\":4,\"time_zone\":\"Africa/Johannesburg\"," +
+		"\"location\":{\"address\":\"Long Street 1\",\"latitude\":-33.92,\"longitude\":18.42}}"
+	req, _ := http.NewRequest(http.MethodPost, "/models", strings.NewReader(b))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var m RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.NotEqual(t, uint(0), m.ID)
+	assert.Equal(t, "Cape Town", m.Name)
+	assert.Equal(t, -33.92, m.Location.Latitude)
+	url := fmt.Sprintf("/models/%d", m.ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, url, strings.NewReader("{\"floors\":5,\"location\":{\"address\":\"Long Street 2\"}}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, "Cape Town", m.Name)
+	assert.Equal(t, 5, m.Floors)
+	assert.Equal(t, "Long Street 2", m.Location.Address)
+	assert.Equal(t, 18.42, m.Location.Longitude)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, url, strings.NewReader("{\"name\":\"Kapstadt\",\"type\":\"Office\",\"floors\":1}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var n RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &n)
+	assert.Equal(t, m.ID, n.ID)
+	assert.Equal(t, "Kapstadt", n.Name)
+	assert.Equal(t, "", n.Location.Address)
+	assert.Equal(t, "", n.TimeZone)
+
+	// sensors and their data are deleted together with the model
+	s := Sensor{RoomModelID: m.ID, Name: "Flow", Data: []Data{{Value: 1}, {Value: 2}}}
+	DB.Create(&s)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	var count int
+	DB.Model(&Sensor{}).Where("id = ?", s.ID).Count(&count)
+	assert.Equal(t, 0, count)
+	DB.Model(&Data{}).Where("sensor_id = ?", s.ID).Count(&count)
+	assert.Equal(t, 0, count)
+}
+
+func TestCreateRoomModelInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	models := []string{
+		"{\"type\":\"Office\",\"floors\":1}",
+		"{\"name\":\" \",\"type\":\"Office\",\"floors\":1}",
+		"{\"name\":\"Berlin\",\"floors\":1}",
+		"{\"name\":\"Berlin\",\"type\":\"Office\",\"floors\":0}",
+		"{\"name\":\"Berlin\",\"type\":\"Office\",\"floors\":1,\"location\":{\"latitude\":91}}",
+		"{\"name\":\"Berlin\",\"type\":\"Office\",\"floors\":1,\"location\":{\"longitude\":-181}}",
+		"{\"name\":\"Berlin\",\"type\":\"Office\",\"floors\":1,\"time_zone\":\"Mars/Olympus\"}",
+		"{\"name\":\"Berlin\",\"type\":\"Office\",\"floors\":\"one\"}",
+	}
+
+	for _, m := range models {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/models", strings.NewReader(m))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, m)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPatch, "/models/1", strings.NewReader("{\"floors\":-1}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/models/1000", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestQueryRoomModelsNear(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	m := "{\"name\":\"Hamburg\",\"type\":\"Office\",\"floors\":1," +
+		"\"location\":{\"latitude\":53.5511,\"longitude\":9.9937}}"
+	req, _ := http.NewRequest(http.MethodPost, "/models", strings.NewReader(m))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var hamburg RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &hamburg)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?near=52.52,13.405&radius_km=50", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var q []RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &q)
+	assert.Equal(t, 1, len(q))
+	assert.Equal(t, uint(1), q[0].ID)
+	assert.InDelta(t, 10.75, *q[0].Distance, 0.01)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?near=53.55,10.0", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &q)
+	assert.True(t, len(q) >= 2)
+	assert.Equal(t, hamburg.ID, q[0].ID)
+	assert.InDelta(t, 265.34, *q[1].Distance, 0.01)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?bbox=53,9,54,11", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	q = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &q)
+	assert.Equal(t, 1, len(q))
+	assert.Equal(t, hamburg.ID, q[0].ID)
+	assert.Nil(t, q[0].Distance)
+
+	// a bounding box spanning the antimeridian
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?bbox=50,170,55,-170", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &q)
+	assert.Equal(t, 0, len(q))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/models/%d", hamburg.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
+
+func TestQueryRoomModelsNearInvalid(t *testing.T) {
+	r := SetupRouter()
+	for _, query := range []string{"near=52.52", "near=91,13", "radius_km=10", "near=52.52,13.405&radius_km=-1",
+		"bbox=54,9,53,11", "bbox=a,b,c,d"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/models?"+query, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
+
+func TestQueryRoomModelsFilters(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/models?type=Office&city=berlin&sort=-name", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var q []RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &q)
+	assert.Equal(t, 1, len(q))
+	assert.Equal(t, uint(1), q[0].ID)
+	assert.Equal(t, 2, len(q[0].Sensors))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?sensors=false&limit=1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+
+	q = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &q)
+	assert.Equal(t, 1, len(q))
+	assert.Nil(t, q[0].Sensors)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?city=100%25", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?sort=location", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
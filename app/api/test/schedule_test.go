@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func TestBoundScheduleOverridesBounds(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"upper_bound": 60.0}
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	// 2019-10-01 was a tuesday, the schedule is interpreted in the time zone of the model (UTC+2)
+	DB.Model(&RoomModel{}).Where("id = ?", 1).Update("time_zone", "Europe/Berlin")
+
+	w = httptest.NewRecorder()
+	b := "{\"name\":\"night setback\",\"weekdays\":\"mon,tue\",\"start_time\":\"02:12\",\"end_time\":\"02:18\"," +
+		"\"upper_bound\":58.0}"
+	req, _ = http.NewRequest(http.MethodPost, "/sensors/1/schedules"+backdated, strings.NewReader(b))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var sc BoundSchedule
+	_ = json.Unmarshal(w.Body.Bytes(), &sc)
+	assert.Equal(t, uint(1), sc.SensorID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	expected := "[{\"type\":\"Above Upper Limit\"," +
+		"\"start_data\":{\"id\":4,\"sensor_id\":1,\"value\":58.553765,\"gradient\":-0.00015,\"date\":\"2019-10-01T00:15:32Z\"}," +
+		"\"end_data\":null," +
+		"\"peak_data\":{\"id\":4,\"sensor_id\":1,\"value\":58.553765,\"gradient\":-0.00015,\"date\":\"2019-10-01T00:15:32Z\"}" +
+		"}]"
+	assert.Equal(t, expected, w.Body.String())
+	anomalies := w.Body.String()
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/schedules", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var l []BoundSchedule
+	_ = json.Unmarshal(w.Body.Bytes(), &l)
+	assert.Equal(t, 1, len(l))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/sensors/1/schedules/%d", sc.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	// the deleted schedule still applies to the readings before its deletion
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, anomalies, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/schedules", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "[]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/schedules?history=true", nil)
+	r.ServeHTTP(w, req)
+	l = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &l)
+	if assert.Equal(t, 1, len(l)) {
+		assert.NotNil(t, l[0].ValidTo)
+	}
+	DB.Delete(&BoundSchedule{}, sc.ID)
+
+	DB.Model(&RoomModel{}).Where("id = ?", 1).Update("time_zone", "")
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"upper_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestBoundScheduleKeepsEarlierAnomalies(t *testing.T) {
+	r := SetupRouter()
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 12, 11, 12)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	anomalies := fmt.Sprintf("/sensors/%d/anomalies", s.ID)
+	schedules := fmt.Sprintf("/sensors/%d/schedules", s.ID)
+	b := "{\"name\":\"all day\",\"start_time\":\"00:00\",\"end_time\":\"00:00\",\"lower_bound\":11.5}"
+
+	// a schedule created after the readings does not apply to them
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, schedules, strings.NewReader(b))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, anomalies, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+
+	// unless it is backdated
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, schedules+"?valid_from=2020-01-01%2000:00:00", strings.NewReader(b))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, anomalies, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	var l []Anomaly
+	_ = json.Unmarshal(w.Body.Bytes(), &l)
+	if assert.Equal(t, 1, len(l)) {
+		assert.Equal(t, 11.0, l[0].StartData.Value)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, schedules+"?valid_from=2999-01-01%2000:00:00", strings.NewReader(b))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestBoundScheduleAcrossMidnight(t *testing.T) {
+	b := BoundSchedule{Weekdays: "mon", StartTime: "22:00", EndTime: "06:00"}
+
+	monday := time.Date(2019, 9, 30, 23, 0, 0, 0, time.UTC)
+	assert.True(t, b.Applies(monday, time.UTC))
+	assert.True(t, b.Applies(monday.Add(6*time.Hour), time.UTC))
+	assert.False(t, b.Applies(monday.Add(8*time.Hour), time.UTC))
+	assert.False(t, b.Applies(monday.Add(-3*time.Hour), time.UTC))
+
+	// the schedule only applies while it is in force
+	b.ValidFrom, b.ValidTo = &monday, &monday
+	assert.False(t, b.Applies(monday, time.UTC))
+}
+
+func TestBoundScheduleInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	schedules := []string{
+		"{\"start_time\":\"25:00\",\"end_time\":\"06:00\"}",
+		"{\"start_time\":\"22:00\",\"end_time\":\"6\"}",
+		"{\"weekdays\":\"monday\",\"start_time\":\"22:00\",\"end_time\":\"06:00\"}",
+		"{\"start_time\":\"22:00\",\"end_time\":\"06:00\",\"upper_bound\":\"high\"}",
+	}
+
+	for _, b := range schedules {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/sensors/1/schedules", strings.NewReader(b))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, b)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/sensors/1000/schedules", strings.NewReader(schedules[0]))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/sensors/1/schedules/1000", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+//backdated applies sensor changes to the stored sample readings which all date from 2019
+const backdated = "?valid_from=2019-01-01%2000:00:00"
+
+func querySensorConfigs(t *testing.T, r http.Handler, url string) []SensorConfig {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var configs []SensorConfig
+	_ = json.Unmarshal(w.Body.Bytes(), &configs)
+	return configs
+}
+
+func patchSensor(t *testing.T, r http.Handler, url string, body string) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPatch, url, strings.NewReader(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestSensorConfigs(t *testing.T) {
+	r := SetupRouter()
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+	url := fmt.Sprintf("/sensors/%d", s.ID)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, url+"/data",
+		strings.NewReader(newReadings(time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC), 12, 12, 12, 12)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+	var data []Data
+	_ = json.Unmarshal(w.Body.Bytes(), &data)
+
+	// the raised lower bound is in force for the first two readings only
+	patchSensor(t, r, url+backdated, "{\"lower_bound\":13}")
+	patchSensor(t, r, url+"?valid_from=2019-10-01%2000:02:00", "{\"lower_bound\":null}")
+
+	configs := querySensorConfigs(t, r, url+"/configs?at=2019-10-01%2000:01:00")
+	assert.Equal(t, 1, len(configs))
+	assert.Equal(t, 13.0, *configs[0].LowerBound)
+	assert.Equal(t, "2019-10-01 00:02:00", configs[0].ValidTo.Format(Layout))
+
+	configs = querySensorConfigs(t, r, url+"/configs?at=2019-10-01%2000:05:00")
+	assert.Equal(t, 1, len(configs))
+	assert.Nil(t, configs[0].LowerBound)
+	assert.Nil(t, configs[0].ValidTo)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url+"/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var a []Anomaly
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 1, len(a))
+	assert.Equal(t, BelowLowerLimit, a[0].Type)
+	assert.Equal(t, data[0].ID, a[0].StartData.ID)
+
+	// changes which do not touch the bounds or the mesh keep the history
+	patchSensor(t, r, url+backdated, "{\"name\":\"Renamed\"}")
+	assert.Equal(t, 3, len(querySensorConfigs(t, r, url+"/configs")))
+
+	// the history before the latest version can not be rewritten
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, url+backdated, strings.NewReader("{\"lower_bound\":null}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	// a change at the start of the latest version replaces it
+	patchSensor(t, r, url+"?valid_from=2019-10-01%2000:02:00", "{\"lower_bound\":11}")
+	configs = querySensorConfigs(t, r, url+"/configs")
+	assert.Equal(t, 3, len(configs))
+	assert.Equal(t, 10.0, *configs[0].LowerBound)
+	assert.Equal(t, 13.0, *configs[1].LowerBound)
+	assert.Equal(t, 11.0, *configs[2].LowerBound)
+	assert.Equal(t, "2019-10-01 00:02:00", configs[2].ValidFrom.Format(Layout))
+}
+
+func TestSensorConfigsOfNewSensor(t *testing.T) {
+	r := SetupRouter()
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+	url := fmt.Sprintf("/sensors/%d", s.ID)
+
+	configs := querySensorConfigs(t, r, url+"/configs")
+	assert.Equal(t, 1, len(configs))
+	assert.Equal(t, 10.0, *configs[0].LowerBound)
+
+	// changes without a date apply from now on
+	patchSensor(t, r, url, "{\"lower_bound\":20}")
+	patchSensor(t, r, url, "{\"name\":\"Renamed\"}")
+	configs = querySensorConfigs(t, r, url+"/configs")
+	assert.Equal(t, 2, len(configs))
+	assert.Equal(t, configs[0].ValidTo, configs[1].ValidFrom)
+	assert.Equal(t, 20.0, *configs[1].LowerBound)
+
+	configs = querySensorConfigs(t, r, url+"/configs?at=2019-10-01%2000:00:00")
+	assert.Equal(t, 1, len(configs))
+	assert.Equal(t, 10.0, *configs[0].LowerBound)
+}
+
+func TestSensorConfigsInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	for url, code := range map[string]int{"/sensors/1/configs?at=yesterday": 400, "/sensors/9999/configs": 404} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, url)
+	}
+
+	for _, from := range []string{"yesterday", "2999-01-01%2000:00:00"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/sensors/1?valid_from="+from, strings.NewReader("{\"lower_bound\":59}"))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, from)
+	}
+}
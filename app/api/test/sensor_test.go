@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestQuerySensors(t *testing.T) {
@@ -221,7 +223,7 @@ func TestPatchSensor(t *testing.T) {
 	r := SetupRouter()
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"mesh_id": float64(357)}
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -233,7 +235,7 @@ func TestPatchSensor(t *testing.T) {
 	w = httptest.NewRecorder()
 	// change data back
 	i = map[string]interface{}{"mesh_id": nil}
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -249,7 +251,7 @@ func TestPatchSensorAllFields(t *testing.T) {
 	r := SetupRouter()
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"mesh_id": float64(357), "lower_bound": 7.6, "upper_bound": 7.6, "gradient_bound": 7.6}
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -265,7 +267,7 @@ func TestPatchSensorAllFields(t *testing.T) {
 
 	// change data back
 	i = map[string]interface{}{"mesh_id": nil, "lower_bound": nil, "upper_bound": nil, "gradient_bound": nil}
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 	fmt.Println(w.Body.String())
@@ -298,7 +300,7 @@ func TestPatchSensorIDMalformed(t *testing.T) {
 func TestPatchSensorIgnoreInaccessibleFields(t *testing.T) {
 	r := SetupRouter()
 	w := httptest.NewRecorder()
-	i := map[string]interface{}{"name": "name", "description": "description"}
+	i := map[string]interface{}{"id": 5.0, "import_name": "import.csv"}
 	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
@@ -306,8 +308,8 @@ func TestPatchSensorIgnoreInaccessibleFields(t *testing.T) {
 	var m map[string]interface{}
 	_ = json.Unmarshal(w.Body.Bytes(), &m)
 
-	assert.NotEqual(t, i["name"], m["name"])
-	assert.NotEqual(t, i["description"], m["description"])
+	assert.NotEqual(t, i["id"], m["id"])
+	assert.NotEqual(t, i["import_name"], m["import_name"])
 }
 
 func TestPatchSensorIgnoreNewField(t *testing.T) {
@@ -364,7 +366,7 @@ func TestQueryAnomaliesLowerBound(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	i := map[string]interface{}{"lower_bound": 59.0}
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
@@ -391,7 +393,7 @@ func TestQueryAnomaliesLowerBound(t *testing.T) {
 	i = map[string]interface{}{"lower_bound": nil}
 
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
@@ -401,7 +403,7 @@ func TestQueryAnomaliesUpperBound(t *testing.T) {
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"upper_bound": 58.8}
 
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -422,7 +424,7 @@ func TestQueryAnomaliesUpperBound(t *testing.T) {
 	i = map[string]interface{}{"upper_bound": nil}
 
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
@@ -432,7 +434,7 @@ func TestQueryAnomaliesUpwardGradient(t *testing.T) {
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"gradient_bound": 0.002}
 
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -457,7 +459,7 @@ func TestQueryAnomaliesUpwardGradient(t *testing.T) {
 	i = map[string]interface{}{"gradient_bound": nil}
 
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
@@ -467,7 +469,7 @@ func TestQueryAnomaliesDownwardGradient(t *testing.T) {
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"gradient_bound": 0.0029}
 
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -487,7 +489,7 @@ func TestQueryAnomaliesDownwardGradient(t *testing.T) {
 	i = map[string]interface{}{"gradient_bound": nil}
 
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
@@ -497,7 +499,7 @@ func TestQueryAnomaliesCombined(t *testing.T) {
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"gradient_bound": 0.002, "upper_bound": 58.59}
 
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -527,7 +529,7 @@ func TestQueryAnomaliesCombined(t *testing.T) {
 	i = map[string]interface{}{"gradient_bound": nil, "upper_bound": nil}
 
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
@@ -537,7 +539,7 @@ func TestQueryAnomaliesTimePeriod(t *testing.T) {
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"upper_bound": 59}
 
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -557,7 +559,7 @@ func TestQueryAnomaliesTimePeriod(t *testing.T) {
 	i = map[string]interface{}{"upper_bound": nil}
 
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
@@ -567,7 +569,7 @@ func TestQueryAnomaliesOutsideTimePeriod(t *testing.T) {
 	w := httptest.NewRecorder()
 	i := map[string]interface{}{"upper_bound": 59}
 
-	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 
@@ -583,7 +585,7 @@ func TestQueryAnomaliesOutsideTimePeriod(t *testing.T) {
 	i = map[string]interface{}{"upper_bound": nil}
 
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(AsJSON(i)))
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
 	r.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
@@ -601,3 +603,370 @@ func TestQueryAnomaliesNoBoundaries(t *testing.T) {
 
 	assert.Equal(t, expected, w.Body.String())
 }
+
+func TestQuerySuggestedBounds(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors/1/suggested-bounds?percentile=75", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	expected := "{\"percentile\":75,\"data_count\":5," +
+		"\"lower_bound\":{\"value\":58.57202,\"anomalies\":1}," +
+		"\"upper_bound\":{\"value\":58.85,\"anomalies\":1}," +
+		"\"gradient_bound\":{\"value\":0.00207,\"anomalies\":1}," +
+		"\"anomalies\":3}"
+
+	assert.Equal(t, expected, w.Body.String())
+}
+
+func TestQuerySuggestedBoundsEmptyPeriod(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors/1/suggested-bounds?start_date=2018-10-01 00:00:00&"+
+		"end_date=2018-10-02 00:00:00", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var m map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, 0.0, m["data_count"])
+}
+
+func TestQuerySuggestedBoundsPercentileOutOfRange(t *testing.T) {
+	r := SetupRouter()
+
+	for _, p := range []string{"50", "100.5", "p", "NaN", "Inf"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/sensors/1/suggested-bounds?percentile="+p, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors/1000/suggested-bounds", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestPreviewAnomalies(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"upper_bound": 58.8}
+	req, _ := http.NewRequest(http.MethodPost, "/sensors/1/anomalies/preview", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	expected := "[" +
+		"{\"type\":\"Above Upper Limit\"," +
+		"\"start_data\":{\"id\":1,\"sensor_id\":1,\"value\":58.85,\"gradient\":0,\"date\":\"2019-10-01T00:00:00Z\"}," +
+		"\"end_data\":{\"id\":2,\"sensor_id\":1,\"value\":59.50921,\"gradient\":0.00207,\"date\":\"2019-10-01T00:05:18Z\"}," +
+		"\"peak_data\":{\"id\":2,\"sensor_id\":1,\"value\":59.50921,\"gradient\":0.00207,\"date\":\"2019-10-01T00:05:18Z\"}" +
+		"}]"
+	assert.Equal(t, expected, w.Body.String())
+
+	// nothing has been persisted
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var m map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, nil, m["upper_bound"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors/1/anomalies", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestPreviewAnomaliesOverridesStoredBounds(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"upper_bound": 58.8}
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"upper_bound": nil, "gradient_bound": 0.0029, "maintenance": "ignore"}
+	req, _ = http.NewRequest(http.MethodPost, "/sensors/1/anomalies/preview?start_date=2019-10-01 00:05:00",
+		strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	expected := "[{\"type\":\"High Downward Gradient\"," +
+		"\"start_data\":{\"id\":3,\"sensor_id\":1,\"value\":58.599918,\"gradient\":-0.00291,\"date\":\"2019-10-01T00:10:31Z\"}," +
+		"\"end_data\":null," +
+		"\"peak_data\":{\"id\":3,\"sensor_id\":1,\"value\":58.599918,\"gradient\":-0.00291,\"date\":\"2019-10-01T00:10:31Z\"}" +
+		"}]"
+	assert.Equal(t, expected, w.Body.String())
+
+	i = map[string]interface{}{"upper_bound": nil}
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestPreviewAnomaliesWrongType(t *testing.T) {
+	r := SetupRouter()
+
+	for _, i := range []string{"{\"gradient_bound\":\"value\"}", "{\"maintenance\":1}", "{\"maintenance\":\"always\"}"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/sensors/1/anomalies/preview", strings.NewReader(i))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/sensors/1000/anomalies/preview", strings.NewReader("{}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestCreateUpdateDeleteSensor(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"room_model_id": 1, "name": "Supply Pressure", "measurement_unit": "bar",
+		"range": "0-6", "upper_bound": 4.5}
+	req, _ := http.NewRequest(http.MethodPost, "/sensors", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var m map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, "Supply Pressure", m["name"])
+	assert.Equal(t, 4.5, m["upper_bound"])
+	url := fmt.Sprintf("/sensors/%.0f", m["id"])
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"name": "Return Pressure", "description": "after the pump", "measurement_unit": "kPa",
+		"range": "0-600"}
+	req, _ = http.NewRequest(http.MethodPatch, url, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, i["name"], m["name"])
+	assert.Equal(t, i["description"], m["description"])
+	assert.Equal(t, i["measurement_unit"], m["measurement_unit"])
+	assert.Equal(t, i["range"], m["range"])
+
+	// reassign to another model
+	w = httptest.NewRecorder()
+	b := "{\"name\":\"Annex\",\"type\":\"Office\",\"floors\":1}"
+	req, _ = http.NewRequest(http.MethodPost, "/models", strings.NewReader(b))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	var n map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &n)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"room_model_id": n["id"]}
+	req, _ = http.NewRequest(http.MethodPatch, url, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, n["id"], m["room_model_id"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/models/%.0f", n["id"]), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
+
+func TestDeleteSensorMoveData(t *testing.T) {
+	r := SetupRouter()
+	s := Sensor{RoomModelID: 1, Name: "Outside Temperature", Data: []Data{{Value: 1}, {Value: 2}}}
+	DB.Create(&s)
+	replacement := Sensor{RoomModelID: 1, Name: "Outside Temperature 2", Data: []Data{{Value: 3}}}
+	DB.Create(&replacement)
+
+	for _, to := range []string{"yes", "9999", fmt.Sprint(s.ID)} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/sensors/%d?move_data_to=%s", s.ID, to), nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, to)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/sensors/%d?move_data_to=%d", s.ID, replacement.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+
+	var count int
+	DB.Model(&Data{}).Where("sensor_id = ?", s.ID).Count(&count)
+	assert.Equal(t, 0, count)
+	DB.Model(&Data{}).Where("sensor_id = ?", replacement.ID).Count(&count)
+	assert.Equal(t, 3, count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/sensors/%d", replacement.ID), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+	DB.Model(&Data{}).Where("sensor_id = ?", replacement.ID).Count(&count)
+	assert.Equal(t, 0, count)
+}
+
+func TestCreateSensorInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	sensors := []string{
+		"{\"room_model_id\":1}",
+		"{\"room_model_id\":1000,\"name\":\"Flow\"}",
+		"{\"room_model_id\":1,\"name\":\"Flow\",\"upper_bound\":\"high\"}",
+	}
+
+	for _, s := range sensors {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/sensors", strings.NewReader(s))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, s)
+	}
+
+	for _, s := range []string{"{\"name\":\"\"}", "{\"room_model_id\":1000}", "{\"room_model_id\":1.5}", "{\"range\":5}"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(s))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, s)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/sensors/1000", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestQuerySensorsFilters(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors?name=FLOW&measurement_unit=%C2%B0C", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var s []Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	assert.Equal(t, 1, len(s))
+	assert.Equal(t, uint(1), s[0].ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors?room_model_id=1&sort=-name&limit=1&offset=1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+
+	s = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	assert.Equal(t, 1, len(s))
+	assert.Equal(t, "Flow Temperature", s[0].Name)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors?room_model_id=1&offset=1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	s = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	assert.Equal(t, 1, len(s))
+	assert.Equal(t, uint(2), s[0].ID)
+
+	w = httptest.NewRecorder()
+	i := map[string]interface{}{"lower_bound": 59.0}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors?room_model_id=1&anomaly_status=active", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+
+	s = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	assert.Equal(t, 1, len(s))
+	assert.Equal(t, uint(1), s[0].ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors?room_model_id=1&anomaly_status=inactive", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	s = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	assert.Equal(t, 1, len(s))
+	assert.Equal(t, uint(2), s[0].ID)
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"lower_bound": nil}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestQuerySensorsAnomalyStatusLookback(t *testing.T) {
+	r := SetupRouter()
+	m, active := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"name": "Quiet", "room_model_id": m.ID, "lower_bound": 10.0}
+	req, _ := http.NewRequest(http.MethodPost, "/sensors", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+	var inactive Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &inactive)
+
+	// the anomaly of the first sensor starts within the lookback and goes on, the one of the second sensor ended
+	now := time.Now().UTC().Truncate(time.Second)
+	readings := map[uint][]map[string]interface{}{
+		active.ID: {{"value": 12, "date": now.Add(-48 * time.Hour)}, {"value": 12, "date": now.Add(-2 * time.Hour)},
+			{"value": 5, "date": now.Add(-time.Hour)}, {"value": 5, "date": now}},
+		inactive.ID: {{"value": 5, "date": now.Add(-48 * time.Hour)}, {"value": 12, "date": now}},
+	}
+	for id, data := range readings {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", id), strings.NewReader(AsJSON(data)))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 201, w.Code)
+	}
+
+	for status, id := range map[string]uint{"active": active.ID, "inactive": inactive.ID} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/sensors?room_model_id=%d&anomaly_status=%s", m.ID, status), nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+
+		var s []Sensor
+		_ = json.Unmarshal(w.Body.Bytes(), &s)
+		assert.Equal(t, 1, len(s), status)
+		if len(s) == 1 {
+			assert.Equal(t, id, s[0].ID, status)
+		}
+	}
+}
+
+func TestQuerySensorsFiltersInvalid(t *testing.T) {
+	r := SetupRouter()
+	for _, query := range []string{"sort=unknown", "sort=latest_data", "limit=-1", "limit=1001", "offset=a",
+		"room_model_id=a", "anomaly_status=maybe"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/sensors?"+query, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
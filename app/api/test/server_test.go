@@ -0,0 +1,115 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	"github.com/vi-sense/vi-sense/app/config"
+)
+
+//writeTestCertificate writes a self-signed certificate for localhost and its key to the directory
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "localhost"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), IsCA: true,
+		BasicConstraintsValid: true, KeyUsage: x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	_ = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "visense-certs")
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeTestCertificate(t, dir)
+
+	c := config.Server{TLSAddr: ":44344", CertFile: certFile, KeyFile: keyFile, MinTLSVersion: tls.VersionTLS13}
+	tlsConfig, err := NewTLSConfig(c)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tlsConfig.Certificates))
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+
+	c.ClientCAFile = certFile
+	tlsConfig, err = NewTLSConfig(c)
+	assert.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, tlsConfig.ClientAuth)
+
+	c.ClientCAFile = keyFile
+	_, err = NewTLSConfig(c)
+	assert.Error(t, err)
+
+	// a missing certificate fails instead of falling back to HTTP
+	c = config.Server{Addr: "127.0.0.1:0", TLSAddr: "127.0.0.1:0", CertFile: filepath.Join(dir, "missing.pem"),
+		KeyFile: keyFile}
+	assert.Error(t, Serve(c, http.NotFoundHandler()))
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	for addr, location := range map[string]string{
+		":44344": "https://example.com:44344/models?limit=1",
+		":443":   "https://example.com/models?limit=1",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com:8080/models?limit=1", nil)
+		RedirectToHTTPS(addr).ServeHTTP(w, req)
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, location, w.Header().Get("Location"))
+	}
+}
+
+func TestClientCertificateAuthentication(t *testing.T) {
+	env := map[string]string{"AUTH_DISABLED": "false", "API_KEYS": "dashboard:" + testAPIKey, "ADMIN_USERS": "gateway-1",
+		"HTTPS_ADDR": ":44344", "TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem", "TLS_CLIENT_CA_FILE": "ca.pem"}
+	for k, v := range env {
+		_ = os.Setenv(k, v)
+	}
+	r := SetupRouter()
+	for k := range env {
+		_ = os.Unsetenv(k)
+	}
+	_ = os.Setenv("AUTH_DISABLED", "true")
+
+	// the certificate chain has been verified by the TLS handshake already
+	req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{
+		{{Subject: pkix.Name{CommonName: "gateway-1"}}},
+	}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var me Me
+	_ = json.Unmarshal(w.Body.Bytes(), &me)
+	assert.Equal(t, "gateway-1", me.Name)
+	assert.Equal(t, CertAuth, me.Method)
+
+	req, _ = http.NewRequest(http.MethodGet, "/me", nil)
+	req.TLS = &tls.ConnectionState{}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+)
+
+//openStream connects to the event stream of the model, cancel closes the connection
+func openStream(t *testing.T, url string, lastEventID string) (*bufio.Reader, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req = req.WithContext(ctx)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		cancel()
+		t.FailNow()
+	}
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	return bufio.NewReader(res.Body), cancel
+}
+
+//readStreamEvent reads the next event of the stream and returns its id line and data
+func readStreamEvent(t *testing.T, r *bufio.Reader) (string, Event) {
+	var id string
+	var e Event
+	for {
+		line, err := r.ReadString('\n')
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		line = strings.TrimRight(line, "\n")
+
+		switch {
+		case line == "":
+			return id, e
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e))
+		}
+	}
+}
+
+func TestStreamRoomModel(t *testing.T) {
+	r := SetupRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+	url := fmt.Sprintf("%s/models/%d/stream", server.URL, m.ID)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start, 5)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	// the stream starts with the status of every sensor
+	stream, cancel := openStream(t, url, "")
+	_, e := readStreamEvent(t, stream)
+	assert.Equal(t, StatusEvent, e.Type)
+	assert.Equal(t, s.ID, e.SensorID)
+	assert.Equal(t, 5.0, e.Data.Value)
+	assert.Equal(t, BelowLowerLimit, e.Anomaly.Type)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start.Add(time.Hour), 15)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	id, e := readStreamEvent(t, stream)
+	assert.Equal(t, DataEvent, e.Type)
+	assert.Equal(t, 15.0, e.Data.Value)
+	assert.Equal(t, strconv.FormatUint(e.ID, 10), id)
+	_, e = readStreamEvent(t, stream)
+	assert.Equal(t, AnomalyEndEvent, e.Type)
+	cancel()
+
+	// events sent while the client was disconnected are received after reconnecting
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(start.Add(2*time.Hour), 4)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	stream, cancel = openStream(t, url, id)
+	defer cancel()
+	_, e = readStreamEvent(t, stream)
+	assert.Equal(t, AnomalyEndEvent, e.Type)
+	_, e = readStreamEvent(t, stream)
+	assert.Equal(t, DataEvent, e.Type)
+	assert.Equal(t, 4.0, e.Data.Value)
+	_, e = readStreamEvent(t, stream)
+	assert.Equal(t, AnomalyStartEvent, e.Type)
+}
+
+func TestStreamRoomModelHistory(t *testing.T) {
+	r := SetupRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	m, s := createTestSensor(t, r)
+	defer deleteTestModel(r, m)
+	url := fmt.Sprintf("%s/models/%d/stream", server.URL, m.ID)
+
+	// more readings than past events are kept
+	values := make([]float64, 1100)
+	for i := range values {
+		values[i] = float64(20 + i%2)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/sensors/%d/data", s.ID),
+		strings.NewReader(newReadings(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), values...)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+
+	stream, cancel := openStream(t, url, "")
+	_, e := readStreamEvent(t, stream)
+	cancel()
+	assert.Equal(t, StatusEvent, e.Type)
+	last := e.ID
+
+	stream, cancel = openStream(t, url, strconv.FormatUint(last-3, 10))
+	for i := uint64(2); i <= 3; i++ {
+		_, e = readStreamEvent(t, stream)
+		assert.Equal(t, DataEvent, e.Type)
+		assert.Equal(t, last-4+i, e.ID)
+	}
+	_, e = readStreamEvent(t, stream)
+	assert.Equal(t, last, e.ID)
+	assert.Equal(t, 21.0, e.Data.Value)
+	cancel()
+
+	// the oldest readings are no longer known
+	stream, cancel = openStream(t, url, strconv.FormatUint(last-1100, 10))
+	defer cancel()
+	_, e = readStreamEvent(t, stream)
+	assert.Equal(t, StatusEvent, e.Type)
+}
+
+func TestStreamRoomModelInvalid(t *testing.T) {
+	r := SetupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/models/1000/stream", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/stream", nil)
+	req.Header.Set("Last-Event-ID", "malformed")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	. "github.com/vi-sense/vi-sense/app/api"
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+func TestSensorTags(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	i := map[string]interface{}{"tags": []map[string]string{{"key": "system", "value": "heating circuit 1"},
+		{"key": "side", "value": "flow"}}, "lower_bound": 59.0}
+	req, _ := http.NewRequest(http.MethodPatch, "/sensors/1"+backdated, strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var s Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &s)
+	assert.Equal(t, 2, len(s.Tags))
+
+	w = httptest.NewRecorder()
+	i = map[string]interface{}{"tags": []map[string]string{{"key": "system", "value": "heating circuit 1"}}}
+	req, _ = http.NewRequest(http.MethodPatch, "/sensors/2", strings.NewReader(AsJSON(i)))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors?tag=system:heating+circuit+1&tag=side", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var sensors []Sensor
+	_ = json.Unmarshal(w.Body.Bytes(), &sensors)
+	assert.Equal(t, 1, len(sensors))
+	assert.Equal(t, uint(1), sensors[0].ID)
+	assert.Equal(t, "flow", sensors[0].Tags[1].Value)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/anomalies?tag=side:flow", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var a []SensorAnomalies
+	_ = json.Unmarshal(w.Body.Bytes(), &a)
+	assert.Equal(t, 1, len(a))
+	assert.Equal(t, uint(1), a[0].SensorID)
+	assert.Equal(t, 2, a[0].Summary.Count)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/data?tag=system&limit=2", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var d []SensorData
+	_ = json.Unmarshal(w.Body.Bytes(), &d)
+	assert.Equal(t, 2, len(d))
+	assert.Equal(t, 2, len(d[0].Data))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models/1/aggregates?tag=side:flow", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var agg []Aggregate
+	_ = json.Unmarshal(w.Body.Bytes(), &agg)
+	assert.Equal(t, 1, len(agg))
+	assert.Equal(t, 1, agg[0].Sensors)
+	assert.Equal(t, 59.50921, agg[0].Max)
+
+	for _, id := range []int{1, 2} {
+		w = httptest.NewRecorder()
+		i = map[string]interface{}{"tags": []Tag{}, "lower_bound": nil}
+		req, _ = http.NewRequest(http.MethodPatch, fmt.Sprintf("/sensors/%d", id)+backdated, strings.NewReader(AsJSON(i)))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sensors?tag=system", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestRoomModelTags(t *testing.T) {
+	r := SetupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPatch, "/models/1",
+		strings.NewReader("{\"tags\":[{\"key\":\"portfolio\",\"value\":\"north\"}]}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var m RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &m)
+	assert.Equal(t, []Tag{{Key: "portfolio", Value: "north"}}, m.Tags)
+
+	// tags which are not part of the body are kept
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/models/1", strings.NewReader("{\"floors\":3}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?tag=portfolio:north", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var q []RoomModel
+	_ = json.Unmarshal(w.Body.Bytes(), &q)
+	assert.Equal(t, 1, len(q))
+	assert.Equal(t, uint(1), q[0].ID)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/models?tag=portfolio:south", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "[]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/models/1", strings.NewReader("{\"tags\":[]}"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestTagsInvalid(t *testing.T) {
+	r := SetupRouter()
+	for _, body := range []string{"{\"tags\":[{\"key\":\"\",\"value\":\"a\"}]}",
+		"{\"tags\":[{\"key\":\"a\"},{\"key\":\"a\"}]}", "{\"tags\":\"a\"}"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/sensors/1", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, body)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors?tag=:a", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
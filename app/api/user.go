@@ -0,0 +1,240 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"net/http"
+)
+
+//Me describes the client of the request
+type Me struct {
+	Name   string     `json:"name"`
+	Method AuthMethod `json:"method"`
+	Role   Role       `json:"role"`
+	// RoomModelIDs contains the granted room models, it is omitted for admins who may access all models
+	RoomModelIDs []uint `json:"room_model_ids,omitempty"`
+}
+
+//QueryMe godoc
+//@Summary Query current user
+//@Description Query the name, the role and the granted room models of the client of the request.
+//@Description Everybody is an admin while authentication is disabled.
+//@Tags users
+//@Produce json
+//@Success 200 {object} Me
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /me [get]
+func QueryMe(c *gin.Context) (int, string) {
+	acc := accessOf(c)
+	r := Me{Role: acc.role}
+	if p, ok := c.Get(principalKey); ok {
+		r.Name, r.Method = p.(*Principal).Name, p.(*Principal).Method
+	}
+	if !acc.all() {
+		r.RoomModelIDs = acc.modelIDs()
+	}
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//QueryUsers godoc
+//@Summary Query users
+//@Description Query all registered users with their grants. Requires the admin role.
+//@Tags users
+//@Produce json
+//@Success 200 {array} model.User
+//@Failure 403 {string} string "forbidden"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /users [get]
+func QueryUsers(c *gin.Context) (int, string) {
+	r := make([]User, 0)
+	DB.Preload("Grants").Order("id").Find(&r)
+
+	return http.StatusOK, AsJSON(r)
+}
+
+//QueryUser godoc
+//@Summary Query user
+//@Description Query a single user with their grants. Requires the admin role.
+//@Tags users
+//@Produce json
+//@Param id path int true "User ID"
+//@Success 200 {object} model.User
+//@Failure 403 {string} string "forbidden"
+//@Failure 404 {string} string "not found"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /users/{id} [get]
+func QueryUser(c *gin.Context) (int, string) {
+	var u User
+	id := c.Param("id")
+	DB.Preload("Grants").First(&u, id)
+	if u.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("User %s not found.", id)})
+	}
+
+	return http.StatusOK, AsJSON(&u)
+}
+
+//CreateUser godoc
+//@Summary Create user
+//@Description Registers a user with a role and the room models they may access. The name has to match the name of
+//@Description their API key or the subject of their bearer tokens. Admins may access all models regardless of their
+//@Description grants. Requires the admin role.
+//@Tags users
+//@Accept json
+//@Produce json
+//@Param user body model.User true "User"
+//@Success 201 {object} model.User
+//@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
+//@Failure 409 {string} string "conflict"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /users [post]
+func CreateUser(c *gin.Context) (int, string) {
+	var u User
+	if err := c.ShouldBindJSON(&u); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	u.ID = 0
+
+	return saveUser(c, &u, http.StatusCreated)
+}
+
+//UpdateUser godoc
+//@Summary Update user
+//@Description Replaces the name, the role and the grants of a user. Requires the admin role.
+//@Tags users
+//@Accept json
+//@Produce json
+//@Param id path int true "User ID"
+//@Param user body model.User true "User"
+//@Success 200 {object} model.User
+//@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
+//@Failure 404 {string} string "not found"
+//@Failure 409 {string} string "conflict"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /users/{id} [put]
+func UpdateUser(c *gin.Context) (int, string) {
+	var current User
+	id := c.Param("id")
+	DB.First(&current, id)
+	if current.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("User %s not found.", id)})
+	}
+
+	var u User
+	if err := c.ShouldBindJSON(&u); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+	u.ID = current.ID
+
+	return saveUser(c, &u, http.StatusOK)
+}
+
+//DeleteUser godoc
+//@Summary Delete user
+//@Description Deletes a user together with their grants. Requires the admin role.
+//@Tags users
+//@Param id path int true "User ID"
+//@Success 204 {string} string "no content"
+//@Failure 403 {string} string "forbidden"
+//@Failure 404 {string} string "not found"
+//@Failure 500 {string} string "internal server error"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /users/{id} [delete]
+func DeleteUser(c *gin.Context) (int, string) {
+	var u User
+	id := c.Param("id")
+	DB.Preload("Grants").First(&u, id)
+	if u.ID == 0 {
+		return http.StatusNotFound, AsJSON(gin.H{"error": fmt.Sprintf("User %s not found.", id)})
+	}
+
+	tx := DB.Begin()
+	err := tx.Where("user_id = ?", u.ID).Delete(&Grant{}).Error
+	if err == nil {
+		err = tx.Delete(&u).Error
+	}
+	if err == nil {
+		err = recordAudit(tx, c, "user", u.ID, &u, nil)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return http.StatusNoContent, ""
+}
+
+//saveUser validates the user and stores it with its grants replacing the current ones
+func saveUser(c *gin.Context, u *User, status int) (int, string) {
+	if err := u.Validate(); err != nil {
+		return http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	var other User
+	DB.Where("name = ? AND id <> ?", u.Name, u.ID).First(&other)
+	if other.ID != 0 {
+		return http.StatusConflict, AsJSON(gin.H{"error": fmt.Sprintf("User %s already exists.", u.Name)})
+	}
+
+	granted := make(map[uint]bool, len(u.Grants))
+	grants := make([]Grant, 0, len(u.Grants))
+	for _, g := range u.Grants {
+		var q RoomModel
+		DB.First(&q, g.RoomModelID)
+		if q.ID == 0 {
+			return http.StatusBadRequest, AsJSON(gin.H{"error": fmt.Sprintf("Model %d not found.", g.RoomModelID)})
+		}
+		if !granted[q.ID] {
+			granted[q.ID] = true
+			grants = append(grants, Grant{RoomModelID: q.ID})
+		}
+	}
+	u.Grants = nil
+
+	tx := DB.Begin()
+	var before interface{}
+	if u.ID != 0 {
+		var current User
+		tx.Preload("Grants").First(&current, u.ID)
+		before = auditJSON(&current)
+	}
+
+	err := tx.Save(u).Error
+	if err == nil {
+		err = tx.Where("user_id = ?", u.ID).Delete(&Grant{}).Error
+	}
+	for i := 0; err == nil && i < len(grants); i++ {
+		grants[i].UserID = u.ID
+		err = tx.Create(&grants[i]).Error
+	}
+	if err == nil {
+		u.Grants = grants
+		err = recordAudit(tx, c, "user", u.ID, before, u)
+	}
+	if err != nil {
+		tx.Rollback()
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return http.StatusInternalServerError, AsJSON(gin.H{"error": err.Error()})
+	}
+
+	return status, AsJSON(u)
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	. "github.com/vi-sense/vi-sense/app/model"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	//liveHeartbeat is the interval of the heartbeat events of idle and busy connections alike
+	liveHeartbeat = 30 * time.Second
+	//liveWriteTimeout is the time a client has to accept a single message before its connection is closed
+	liveWriteTimeout = 10 * time.Second
+)
+
+//LiveRequest is a message of a client changing the subscriptions of its connection
+type LiveRequest struct {
+	// Action is either subscribe or unsubscribe
+	Action       string `json:"action" example:"subscribe"`
+	RoomModelIDs []uint `json:"room_model_ids"`
+	SensorIDs    []uint `json:"sensor_ids"`
+}
+
+//LiveUpdates godoc
+//@Summary Live updates
+//@Description Upgrades the connection to a WebSocket which receives every new reading of the subscribed room models
+//@Description and sensors as data event and the anomalies they start or end as anomaly_start and anomaly_end events.
+//@Description The initial subscriptions are passed as query parameters, clients change them by sending LiveRequest
+//@Description messages. A heartbeat event is sent every 30 seconds. Clients which do not keep up with the events are
+//@Description sent an error event and disconnected.
+//@Tags live
+//@Produce json
+//@Param room_model_id query []int false "RoomModel IDs" collectionFormat(multi)
+//@Param sensor_id query []int false "Sensor IDs" collectionFormat(multi)
+//@Success 101 {object} Event
+//@Failure 400 {string} string "bad request"
+//@Failure 403 {string} string "forbidden"
+//@Failure 404 {string} string "not found"
+//@Security ApiKeyAuth
+//@Security BearerAuth
+//@Router /live [get]
+func LiveUpdates(c *gin.Context) {
+	models, err := parseIDParams(c, "room_model_id")
+	if err != nil {
+		c.String(http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()}))
+		return
+	}
+	sensors, err := parseIDParams(c, "sensor_id")
+	if err != nil {
+		c.String(http.StatusBadRequest, AsJSON(gin.H{"error": err.Error()}))
+		return
+	}
+	acc := accessOf(c)
+	if status, err := checkSubscription(acc, models, sensors); err != nil {
+		c.String(status, AsJSON(gin.H{"error": err.Error()}))
+		return
+	}
+
+	server := websocket.Server{
+		// origins are restricted by the cors settings of the router
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			serveLive(ws, acc, Live.subscribe(models, sensors))
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+//serveLive writes the events of the subscription to the connection until either side closes it
+func serveLive(ws *websocket.Conn, acc *access, sub *subscription) {
+	defer Live.unsubscribe(sub)
+
+	replies := make(chan Event, 1)
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go receiveLiveRequests(ws, acc, sub, replies, done, stop)
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var e Event
+		select {
+		case event, ok := <-sub.events:
+			if !ok {
+				if sub.dropped {
+					sendLive(ws, Event{Type: ErrorEvent, Error: "The client did not keep up with the events."})
+				}
+				return
+			}
+			e = event
+		case e = <-replies:
+		case <-heartbeat.C:
+			e = Event{Type: HeartbeatEvent}
+		case <-done:
+			return
+		}
+
+		if err := sendLive(ws, e); err != nil {
+			return
+		}
+	}
+}
+
+//receiveLiveRequests applies the subscription changes sent by the client, invalid messages are answered with an error
+func receiveLiveRequests(ws *websocket.Conn, acc *access, sub *subscription, replies chan<- Event, done chan<- struct{},
+	stop <-chan struct{}) {
+	defer close(done)
+
+	for {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		var r LiveRequest
+		err := json.Unmarshal([]byte(msg), &r)
+		if err == nil && r.Action != "subscribe" && r.Action != "unsubscribe" {
+			err = &ParamParseError{Param: "action", Value: r.Action}
+		}
+		if err == nil && r.Action == "subscribe" {
+			_, err = checkSubscription(acc, r.RoomModelIDs, r.SensorIDs)
+		}
+		if err != nil {
+			select {
+			case replies <- Event{Type: ErrorEvent, Error: err.Error()}:
+				continue
+			case <-stop:
+				return
+			}
+		}
+
+		Live.update(sub, r.RoomModelIDs, r.SensorIDs, r.Action == "unsubscribe")
+	}
+}
+
+func sendLive(ws *websocket.Conn, e Event) error {
+	if err := ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(ws, e)
+}
+
+//checkSubscription checks whether all room models and sensors exist and are granted
+func checkSubscription(acc *access, models []uint, sensors []uint) (int, error) {
+	for _, id := range models {
+		var q RoomModel
+		DB.First(&q, id)
+		if q.ID == 0 {
+			return http.StatusNotFound, fmt.Errorf("Model %d not found.", id)
+		}
+		if !acc.permits(q.ID) {
+			return http.StatusForbidden, fmt.Errorf("Model %d is not granted.", q.ID)
+		}
+	}
+	for _, id := range sensors {
+		var s Sensor
+		DB.First(&s, id)
+		if s.ID == 0 {
+			return http.StatusNotFound, fmt.Errorf("Sensor %d not found.", id)
+		}
+		if !acc.permits(s.RoomModelID) {
+			return http.StatusForbidden, fmt.Errorf("Model %d is not granted.", s.RoomModelID)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+//parseIDParams parses all values of a repeatable id query parameter
+func parseIDParams(c *gin.Context, param string) ([]uint, error) {
+	var ids []uint
+	for _, v := range c.QueryArray(param) {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil || id == 0 {
+			return nil, &ParamParseError{Param: param, Value: v}
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+//APIKeys contains the static API keys of machine clients
+type APIKeys struct {
+	// clients maps the hash of every key to the name of its client, looking up hashes does not leak the keys by timing
+	clients map[[sha256.Size]byte]string
+}
+
+//ParseAPIKeys parses a comma separated list of name:key pairs
+func ParseAPIKeys(s string) (*APIKeys, error) {
+	k := APIKeys{clients: make(map[[sha256.Size]byte]string)}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || len(parts[1]) < 16 {
+			return nil, fmt.Errorf("invalid API key entry for %q, expected name:key with a key of at least 16 characters",
+				strings.TrimSpace(parts[0]))
+		}
+		k.clients[sha256.Sum256([]byte(parts[1]))] = strings.TrimSpace(parts[0])
+	}
+	return &k, nil
+}
+
+//Len returns the number of keys
+func (k *APIKeys) Len() int {
+	return len(k.clients)
+}
+
+//Lookup returns the name of the client the key belongs to
+func (k *APIKeys) Lookup(key string) (string, bool) {
+	name, ok := k.clients[sha256.Sum256([]byte(key))]
+	return name, ok
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+//Claims are the claims of a verified token
+type Claims map[string]interface{}
+
+//Subject returns the sub claim
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+//Verifier checks the signature and the registered claims of JWTs
+type Verifier struct {
+	Keys *KeySet
+	// Issuer and Audience are only checked if they are set
+	Issuer   string
+	Audience string
+	// Leeway is the clock skew tolerated when checking exp and nbf
+	Leeway time.Duration
+	// Now returns the current time, time.Now is used if it is nil
+	Now func() time.Time
+}
+
+//ErrInvalidToken is returned for every token which can not be verified, the cause is deliberately not exposed
+var ErrInvalidToken = errors.New("invalid token")
+
+//algorithms maps the supported signature algorithms to their hash functions
+var algorithms = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+//curveSizes contains the size of the curve each ECDSA algorithm requires
+var curveSizes = map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}
+
+//Verify checks the token in the compact serialization and returns its claims; the token has to be signed by a key
+//of the key set with a supported algorithm and contain an expiry date
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || v.Keys == nil {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if decodeSegment(parts[0], &header) != nil {
+		return nil, ErrInvalidToken
+	}
+	hash, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range v.Keys.candidates(header.Kid, header.Alg) {
+		if verifySignature(k, header.Alg, hash, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if decodeSegment(parts[1], &claims) != nil || claims == nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) checkClaims(c Claims) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+
+	exp, ok := c["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(v.Leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := c["nbf"].(float64); ok && now.Add(v.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token not valid yet")
+	}
+
+	if v.Issuer != "" {
+		if iss, _ := c["iss"].(string); iss != v.Issuer {
+			return ErrInvalidToken
+		}
+	}
+
+	if v.Audience != "" {
+		found := false
+		switch aud := c["aud"].(type) {
+		case string:
+			found = aud == v.Audience
+		case []interface{}:
+			for _, a := range aud {
+				found = found || a == v.Audience
+			}
+		}
+		if !found {
+			return ErrInvalidToken
+		}
+	}
+	return nil
+}
+
+//verifySignature checks the signature with a key matching the type of the algorithm
+func verifySignature(k key, alg string, hash crypto.Hash, signed []byte, signature []byte) bool {
+	switch alg[:2] {
+	case "HS":
+		if k.secret == nil {
+			return false
+		}
+		mac := hmac.New(hash.New, k.secret)
+		mac.Write(signed)
+		return hmac.Equal(signature, mac.Sum(nil))
+
+	case "RS":
+		public, ok := k.public.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		return rsa.VerifyPKCS1v15(public, hash, h.Sum(nil), signature) == nil
+
+	case "ES":
+		public, ok := k.public.(*ecdsa.PublicKey)
+		if !ok || curveSizes[alg] != public.Curve.Params().BitSize {
+			return false
+		}
+		// the signature is the concatenation of r and s, each padded to the size of the curve
+		size := (public.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(public, h.Sum(nil), r, s)
+	}
+	return false
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+)
+
+//KeySet contains the keys JWTs are verified with
+type KeySet struct {
+	keys []key
+}
+
+//key is a single verification key, exactly one of public and secret is set
+type key struct {
+	id     string
+	alg    string
+	public interface{}
+	secret []byte
+}
+
+//jsonWebKey is a key of a JSON Web Key Set (RFC 7517), only the members needed to verify signatures are read
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// symmetric
+	K string `json:"k"`
+}
+
+//LoadKeySet reads a JSON Web Key Set from a file
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeySet(data)
+}
+
+//ParseKeySet parses a JSON Web Key Set with RSA (RS256/384/512), EC (ES256/384/512) and symmetric (HS256/384/512) keys;
+//keys which are not meant for signatures are skipped
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	ks := KeySet{}
+	for i, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		k, err := jwk.parse()
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %s", i, err.Error())
+		}
+		ks.keys = append(ks.keys, k)
+	}
+
+	if len(ks.keys) == 0 {
+		return nil, errors.New("the key set contains no signature keys")
+	}
+	return &ks, nil
+}
+
+func (jwk *jsonWebKey) parse() (key, error) {
+	k := key{id: jwk.Kid, alg: jwk.Alg}
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return k, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return k, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 {
+			return k, errors.New("invalid RSA exponent")
+		}
+		k.public = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return k, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return k, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return k, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return k, errors.New("the point is not on the curve")
+		}
+		k.public = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(secret) == 0 {
+			return k, errors.New("invalid symmetric key")
+		}
+		k.secret = secret
+
+	default:
+		return k, fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+
+	return k, nil
+}
+
+//candidates returns the keys a token with the key id and algorithm may be signed with
+func (ks *KeySet) candidates(kid string, alg string) []key {
+	var r []key
+	for _, k := range ks.keys {
+		if (kid != "" && k.id != "" && k.id != kid) || (k.alg != "" && k.alg != alg) {
+			continue
+		}
+		r = append(r, k)
+	}
+	return r
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+//Config contains all settings of the backend
+type Config struct {
+	// Production enables the compression of responses
+	Production bool
+	// Host, Port and Scheme form the public address of the API which is shown in the swagger documentation
+	Host   string
+	Port   int
+	Scheme string
+	// DataDir is the directory of the sample data mounted into the container
+	DataDir  string
+	Server   Server
+	Database Database
+	Storage  Storage
+	CORS     CORS
+	Throttle Throttle
+	Auth     Auth
+}
+
+//Server contains the addresses the backend listens on and the settings of HTTPS, an empty address disables the
+//respective server
+type Server struct {
+	Addr     string
+	TLSAddr  string
+	CertFile string
+	KeyFile  string
+	// MinTLSVersion is one of the tls.VersionTLS constants
+	MinTLSVersion uint16
+	// ClientCAFile contains the certificates client certificates of gateways are verified with, clients without a
+	// certificate have to authenticate otherwise
+	ClientCAFile string
+	// Redirect redirects all HTTP requests to HTTPS instead of serving the API
+	Redirect bool
+	// Fallback serves HTTP only instead of failing if HTTPS can not be set up
+	Fallback bool
+	// ReadTimeout and WriteTimeout apply to whole requests including event streams and websockets, 0 disables them
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+//Database contains the connection settings of the postgres database
+type Database struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+}
+
+//Storage contains the directory uploads are stored in and the maximum upload sizes in bytes
+type Storage struct {
+	Dir               string
+	MaxModelFileSize  int64
+	MaxImageFileSize  int64
+	MaxImportFileSize int64
+	// MaxImportDataSize bounds the decompressed size of all files of an import
+	MaxImportDataSize int64
+}
+
+//CORS contains the origins which may access the API from a browser, * allows all origins
+type CORS struct {
+	AllowOrigins []string
+}
+
+//Throttle contains the number of requests per second and the burst size the API accepts
+type Throttle struct {
+	Rate  int
+	Burst int
+}
+
+//Auth contains the credentials clients authenticate with, running without any has to be requested by Disabled
+type Auth struct {
+	// Disabled serves the API without authentication, it excludes all credentials
+	Disabled bool
+	// APIKeys are comma separated name:key pairs
+	APIKeys string
+	// JWTKeySet is the path of a JSON Web Key Set bearer tokens are verified with
+	JWTKeySet   string
+	JWTIssuer   string
+	JWTAudience string
+	// AdminUsers are the names of API keys or token subjects which are admins without being registered as user
+	AdminUsers []string
+}
+
+//Default returns the configuration used for all settings which are not set
+func Default() *Config {
+	return &Config{
+		Host:    "localhost",
+		Port:    8080,
+		Scheme:  "http",
+		DataDir: "/sample-data",
+		Server:   Server{Addr: ":8080", MinTLSVersion: tls.VersionTLS12},
+		Database: Database{Host: "localhost", Port: 5432, SSLMode: "disable"},
+		Storage: Storage{
+			Dir:               "/uploads",
+			MaxModelFileSize:  100 << 20,
+			MaxImageFileSize:  10 << 20,
+			MaxImportFileSize: 1 << 30,
+			MaxImportDataSize: 4 << 30,
+		},
+		CORS:     CORS{AllowOrigins: []string{"*"}},
+		Throttle: Throttle{Rate: 100, Burst: 100},
+	}
+}
+
+//Load returns the default configuration overridden by the settings of the configuration file, the environment and
+//the command line arguments in this order. The file is passed with -config or CONFIG_FILE and contains KEY=VALUE
+//lines like the docker env files; every setting can be passed as flag named like its key in lower case with dashes,
+//e.g. -postgres-host for POSTGRES_HOST. Empty values override the setting as well, e.g. to clear a list.
+func Load(args []string) (*Config, error) {
+	c := Default()
+	settings := c.settings()
+
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	file := flags.String("config", os.Getenv("CONFIG_FILE"), "path of the configuration file")
+	for _, s := range settings {
+		flags.Var(&flagValue{setting: s, value: s.get()}, s.flag(), s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *file != "" {
+		if err := c.loadFile(*file, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.key); ok {
+			if err := s.set(v); err != nil {
+				return nil, fmt.Errorf("%s: %s", s.key, err.Error())
+			}
+		}
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		if v, ok := f.Value.(*flagValue); ok && err == nil {
+			if e := v.set(v.value); e != nil {
+				err = fmt.Errorf("-%s: %s", f.Name, e.Error())
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, c.Validate()
+}
+
+//loadFile applies the KEY=VALUE lines of the file, blank lines and lines starting with # are skipped
+func (c *Config) loadFile(path string, settings []setting) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		s, ok := byKey[strings.TrimSpace(kv[0])]
+		if !ok {
+			return fmt.Errorf("%s:%d: unknown setting %s", path, n, strings.TrimSpace(kv[0]))
+		}
+		if err := s.set(strings.Trim(strings.TrimSpace(kv[1]), "\"")); err != nil {
+			return fmt.Errorf("%s:%d: %s: %s", path, n, s.key, err.Error())
+		}
+	}
+	return scanner.Err()
+}
+
+//Validate reports the first setting which is out of range
+func (c *Config) Validate() error {
+	switch {
+	case c.Scheme != "http" && c.Scheme != "https":
+		return errors.New("SCHEME has to be http or https.")
+	case c.Port < 1 || c.Port > 65535:
+		return errors.New("PORT has to be between 1 and 65535.")
+	case c.Database.Port < 1 || c.Database.Port > 65535:
+		return errors.New("POSTGRES_PORT has to be between 1 and 65535.")
+	case c.Server.Addr == "" && c.Server.TLSAddr == "":
+		return errors.New("HTTP_ADDR or HTTPS_ADDR has to be set.")
+	case c.Storage.Dir == "":
+		return errors.New("STORAGE_DIR must not be empty.")
+	case c.Storage.MaxModelFileSize <= 0 || c.Storage.MaxImageFileSize <= 0 || c.Storage.MaxImportFileSize <= 0 ||
+		c.Storage.MaxImportDataSize <= 0:
+		return errors.New("File size limits have to be positive.")
+	case len(c.CORS.AllowOrigins) == 0:
+		return errors.New("CORS_ALLOW_ORIGINS must not be empty.")
+	case c.Throttle.Rate <= 0 || c.Throttle.Burst <= 0:
+		return errors.New("THROTTLE_RATE and THROTTLE_BURST have to be positive.")
+	case c.Server.TLSAddr != "" && (c.Server.CertFile == "" || c.Server.KeyFile == ""):
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE are required if HTTPS_ADDR is set.")
+	case c.Server.TLSAddr == "" && (c.Server.ClientCAFile != "" || c.Server.Redirect || c.Server.Fallback):
+		return errors.New("TLS_CLIENT_CA_FILE, HTTP_REDIRECT and HTTP_FALLBACK require HTTPS_ADDR.")
+	case c.Server.Addr == "" && (c.Server.Redirect || c.Server.Fallback):
+		return errors.New("HTTP_REDIRECT and HTTP_FALLBACK require HTTP_ADDR.")
+	case c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0:
+		return errors.New("HTTP_READ_TIMEOUT and HTTP_WRITE_TIMEOUT must not be negative.")
+	case !c.Auth.Disabled && c.Auth.APIKeys == "" && c.Auth.JWTKeySet == "" && c.Server.ClientCAFile == "":
+		return errors.New("API_KEYS, JWT_KEY_SET or TLS_CLIENT_CA_FILE is required unless AUTH_DISABLED is set.")
+	case c.Auth.Disabled && (c.Auth.APIKeys != "" || c.Auth.JWTKeySet != "" || c.Server.ClientCAFile != ""):
+		return errors.New("AUTH_DISABLED excludes API_KEYS, JWT_KEY_SET and TLS_CLIENT_CA_FILE.")
+	}
+	return nil
+}
+
+//Dump writes all settings as KEY=VALUE lines, passwords and keys are redacted
+func (c *Config) Dump(w io.Writer) {
+	for _, s := range c.settings() {
+		v := s.get()
+		if s.secret && v != "" {
+			v = "***"
+		}
+		_, _ = fmt.Fprintf(w, "%s=%s\n", s.key, v)
+	}
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//setting maps a key of the environment to a field of the configuration
+type setting struct {
+	key   string
+	usage string
+	// secret settings are redacted in the dump
+	secret bool
+	isBool bool
+	get    func() string
+	set    func(string) error
+}
+
+//flag returns the name of the command line flag of the setting
+func (s setting) flag() string {
+	return strings.ToLower(strings.Replace(s.key, "_", "-", -1))
+}
+
+//flagValue keeps the value passed on the command line until the file and the environment have been applied
+type flagValue struct {
+	setting
+	value string
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+func (f *flagValue) Set(s string) error {
+	f.value = s
+	return nil
+}
+
+//IsBoolFlag allows to pass boolean settings without a value
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+//settings returns all settings of the configuration in the order they are dumped in
+func (c *Config) settings() []setting {
+	return []setting{
+		boolSetting("PRODUCTION", "compress responses", &c.Production),
+		stringSetting("HOST", "public host name of the API", &c.Host),
+		intSetting("PORT", "public port of the API", &c.Port),
+		stringSetting("SCHEME", "public scheme of the API, http or https", &c.Scheme),
+		stringSetting("DATA_DIR", "directory of the sample data", &c.DataDir),
+
+		stringSetting("HTTP_ADDR", "address the HTTP server listens on", &c.Server.Addr),
+		stringSetting("HTTPS_ADDR", "address the HTTPS server listens on, empty disables HTTPS", &c.Server.TLSAddr),
+		stringSetting("TLS_CERT_FILE", "path of the certificate chain", &c.Server.CertFile),
+		stringSetting("TLS_KEY_FILE", "path of the private key of the certificate", &c.Server.KeyFile),
+		tlsVersionSetting("TLS_MIN_VERSION", "minimum TLS version, 1.0, 1.1, 1.2 or 1.3", &c.Server.MinTLSVersion),
+		stringSetting("TLS_CLIENT_CA_FILE", "path of the CA certificates client certificates are verified with",
+			&c.Server.ClientCAFile),
+		boolSetting("HTTP_REDIRECT", "redirect HTTP requests to HTTPS", &c.Server.Redirect),
+		boolSetting("HTTP_FALLBACK", "serve HTTP only if HTTPS can not be set up", &c.Server.Fallback),
+		durationSetting("HTTP_READ_TIMEOUT", "maximum duration of reading a request, 0 disables it",
+			&c.Server.ReadTimeout),
+		durationSetting("HTTP_WRITE_TIMEOUT", "maximum duration of writing a response, 0 disables it",
+			&c.Server.WriteTimeout),
+
+		stringSetting("POSTGRES_HOST", "host of the database", &c.Database.Host),
+		intSetting("POSTGRES_PORT", "port of the database", &c.Database.Port),
+		stringSetting("POSTGRES_USER", "user of the database", &c.Database.User),
+		secretSetting("POSTGRES_PASSWORD", "password of the database user", &c.Database.Password),
+		stringSetting("POSTGRES_DB", "name of the database", &c.Database.Name),
+		stringSetting("POSTGRES_SSLMODE", "ssl mode of the database connection", &c.Database.SSLMode),
+
+		stringSetting("STORAGE_DIR", "directory uploaded files are stored in", &c.Storage.Dir),
+		sizeSetting("MAX_MODEL_FILE_SIZE", "maximum size of glTF/GLB uploads in bytes", &c.Storage.MaxModelFileSize),
+		sizeSetting("MAX_IMAGE_FILE_SIZE", "maximum size of image uploads in bytes", &c.Storage.MaxImageFileSize),
+		sizeSetting("MAX_IMPORT_FILE_SIZE", "maximum size of imports in bytes", &c.Storage.MaxImportFileSize),
+		sizeSetting("MAX_IMPORT_DATA_SIZE", "maximum decompressed size of the files of an import in bytes",
+			&c.Storage.MaxImportDataSize),
+
+		listSetting("CORS_ALLOW_ORIGINS", "comma separated origins allowed to access the API, * allows all",
+			&c.CORS.AllowOrigins),
+		intSetting("THROTTLE_RATE", "requests per second accepted by the API", &c.Throttle.Rate),
+		intSetting("THROTTLE_BURST", "requests accepted at once by the API", &c.Throttle.Burst),
+
+		boolSetting("AUTH_DISABLED", "serve the API without authentication", &c.Auth.Disabled),
+		secretSetting("API_KEYS", "comma separated name:key pairs, keys need at least 16 characters",
+			&c.Auth.APIKeys),
+		stringSetting("JWT_KEY_SET", "path of a JSON Web Key Set bearer tokens are verified with", &c.Auth.JWTKeySet),
+		stringSetting("JWT_ISSUER", "required issuer of bearer tokens", &c.Auth.JWTIssuer),
+		stringSetting("JWT_AUDIENCE", "required audience of bearer tokens", &c.Auth.JWTAudience),
+		listSetting("ADMIN_USERS", "comma separated names of API keys or token subjects which are admins",
+			&c.Auth.AdminUsers),
+	}
+}
+
+func stringSetting(key string, usage string, v *string) setting {
+	return setting{key: key, usage: usage,
+		get: func() string { return *v },
+		set: func(s string) error { *v = s; return nil },
+	}
+}
+
+func secretSetting(key string, usage string, v *string) setting {
+	s := stringSetting(key, usage, v)
+	s.secret = true
+	return s
+}
+
+func boolSetting(key string, usage string, v *bool) setting {
+	return setting{key: key, usage: usage, isBool: true,
+		get: func() string { return strconv.FormatBool(*v) },
+		set: func(s string) (err error) { *v, err = strconv.ParseBool(s); return },
+	}
+}
+
+func intSetting(key string, usage string, v *int) setting {
+	return setting{key: key, usage: usage,
+		get: func() string { return strconv.Itoa(*v) },
+		set: func(s string) (err error) { *v, err = strconv.Atoi(s); return },
+	}
+}
+
+func sizeSetting(key string, usage string, v *int64) setting {
+	return setting{key: key, usage: usage,
+		get: func() string { return strconv.FormatInt(*v, 10) },
+		set: func(s string) (err error) { *v, err = strconv.ParseInt(s, 10, 64); return },
+	}
+}
+
+func durationSetting(key string, usage string, v *time.Duration) setting {
+	return setting{key: key, usage: usage,
+		get: func() string { return v.String() },
+		set: func(s string) (err error) { *v, err = time.ParseDuration(s); return },
+	}
+}
+
+//tlsVersions maps the names of the supported TLS versions to their constants
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func tlsVersionSetting(key string, usage string, v *uint16) setting {
+	return setting{key: key, usage: usage,
+		get: func() string {
+			for name, version := range tlsVersions {
+				if version == *v {
+					return name
+				}
+			}
+			return ""
+		},
+		set: func(s string) error {
+			version, ok := tlsVersions[s]
+			if !ok {
+				return fmt.Errorf("unsupported TLS version %s", s)
+			}
+			*v = version
+			return nil
+		},
+	}
+}
+
+//listSetting parses comma separated values, surrounding spaces and empty values are dropped
+func listSetting(key string, usage string, v *[]string) setting {
+	return setting{key: key, usage: usage,
+		get: func() string { return strings.Join(*v, ",") },
+		set: func(s string) error {
+			*v = make([]string, 0)
+			for _, e := range strings.Split(s, ",") {
+				if e = strings.TrimSpace(e); e != "" {
+					*v = append(*v, e)
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/vi-sense/vi-sense/app/model"
+)
+
+//Series describes the signal of a single sensor of a heating system
+type Series struct {
+	Name            string
+	Description     string
+	MeasurementUnit string
+	Range           string
+	// Base is the mean value, the daily cycle oscillates by Amplitude around it and peaks at PeakHour (local time)
+	Base      float64
+	Amplitude float64
+	PeakHour  float64
+	// Noise is the standard deviation of the random deviation of every reading
+	Noise float64
+}
+
+//HeatingSystem contains the series of the sensors of a typical heating system
+var HeatingSystem = []Series{
+	{Name: "Flow Temperature", Description: "Heating circuit 1 flow", MeasurementUnit: "°C", Range: "0-100",
+		Base: 60, Amplitude: 8, PeakHour: 6, Noise: 0.3},
+	{Name: "Return Temperature", Description: "Heating circuit 1 return", MeasurementUnit: "°C", Range: "0-100",
+		Base: 45, Amplitude: 6, PeakHour: 7, Noise: 0.3},
+	{Name: "Outdoor Temperature", Description: "Weather station", MeasurementUnit: "°C", Range: "-30-50",
+		Base: 8, Amplitude: 5, PeakHour: 15, Noise: 0.2},
+	{Name: "Hot Water Temperature", Description: "Domestic hot water storage", MeasurementUnit: "°C", Range: "0-100",
+		Base: 55, Amplitude: 3, PeakHour: 19, Noise: 0.4},
+	{Name: "System Pressure", Description: "Boiler", MeasurementUnit: "bar", Range: "0-4",
+		Base: 1.8, Amplitude: 0.1, PeakHour: 6, Noise: 0.02},
+}
+
+//Site is the location of a generated model
+type Site struct {
+	Name     string
+	Location Location
+	TimeZone string
+}
+
+//Sites are the locations generated models are placed at one after another
+var Sites = []Site{
+	{Name: "Berlin", TimeZone: "Europe/Berlin",
+		Location: Location{Address: "Wilhelminenhofstraße 75A, 12459 Berlin", Latitude: 52.4575, Longitude: 13.5262}},
+	{Name: "Cape Town", TimeZone: "Africa/Johannesburg",
+		Location: Location{Address: "Long Street 1, 8001 Cape Town", Latitude: -33.9249, Longitude: 18.4241}},
+	{Name: "Puerto Natales", TimeZone: "America/Punta_Arenas",
+		Location: Location{Address: "Avenida Pedro Montt 1, Puerto Natales", Latitude: -51.7236, Longitude: -72.4875}},
+}
+
+//Options controls the generation of a model and its data
+type Options struct {
+	// Seed makes the generation reproducible, the same options always generate the same data
+	Seed     int64
+	Start    time.Time
+	Duration time.Duration
+	// Interval is the time between two readings, each reading is shifted randomly by up to Jitter which has to be
+	// shorter than the interval
+	Interval time.Duration
+	Jitter   time.Duration
+	// SpikeRate, FlatlineRate and GapRate are the probabilities that a reading is a spike or starts a flatline or a gap
+	SpikeRate    float64
+	FlatlineRate float64
+	GapRate      float64
+	// a spike deviates by SpikeHeight times the amplitude of the series
+	SpikeHeight float64
+	// FlatlineLength and GapLength are the number of readings a flatline repeats or a gap skips
+	FlatlineLength int
+	GapLength      int
+}
+
+//DefaultOptions returns the options for a week of readings every five minutes with occasional faults
+func DefaultOptions() Options {
+	return Options{
+		Seed:           1,
+		Start:          time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC),
+		Duration:       7 * 24 * time.Hour,
+		Interval:       5 * time.Minute,
+		Jitter:         20 * time.Second,
+		SpikeRate:      0.002,
+		FlatlineRate:   0.001,
+		GapRate:        0.001,
+		SpikeHeight:    4,
+		FlatlineLength: 24,
+		GapLength:      36,
+	}
+}
+
+//Model generates a heating system model at the n-th site with a sensor and its data for every series of the
+//HeatingSystem; the import names of the sensors are s1.csv, s2.csv, ... as expected by LoadModels
+func Model(n int, o Options) *RoomModel {
+	site := Sites[n%len(Sites)]
+	name := site.Name
+	if n >= len(Sites) {
+		name = fmt.Sprintf("%s %d", site.Name, n/len(Sites)+1)
+	}
+
+	m := &RoomModel{
+		Name:     name,
+		Type:     "Office",
+		Floors:   3,
+		Location: site.Location,
+		TimeZone: site.TimeZone,
+		Sensors:  make([]Sensor, len(HeatingSystem)),
+	}
+
+	location, err := time.LoadLocation(site.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	rnd := rand.New(rand.NewSource(o.Seed + int64(n)))
+	for i, s := range HeatingSystem {
+		m.Sensors[i] = Sensor{
+			ImportName:      DataFileName(i),
+			Name:            s.Name,
+			Description:     s.Description,
+			MeasurementUnit: s.MeasurementUnit,
+			Range:           s.Range,
+			Data:            Generate(s, o, location, rnd),
+		}
+	}
+	return m
+}
+
+//Generate returns the readings of a series from the start over the duration of the options in chronological order,
+//the daily cycle follows the local time of the location
+func Generate(s Series, o Options, location *time.Location, rnd *rand.Rand) []Data {
+	data := make([]Data, 0)
+	end := o.Start.Add(o.Duration)
+	flatline, gap := 0, 0
+
+	for t := o.Start; t.Before(end); t = t.Add(o.Interval) {
+		if gap > 0 {
+			gap--
+			continue
+		}
+		if rnd.Float64() < o.GapRate {
+			gap = o.GapLength - 1
+			continue
+		}
+
+		date := t
+		if o.Jitter > 0 {
+			date = date.Add(time.Duration(rnd.Int63n(int64(o.Jitter))))
+		}
+		d := Data{Date: Date{Time: date.Truncate(time.Second).UTC()}}
+
+		n := len(data)
+		switch {
+		case flatline > 0 && n > 0:
+			// a stuck sensor repeats its last value
+			flatline--
+			d.Value = data[n-1].Value
+		case rnd.Float64() < o.FlatlineRate && n > 0:
+			flatline = o.FlatlineLength - 1
+			d.Value = data[n-1].Value
+		default:
+			d.Value = s.valueAt(date.In(location)) + rnd.NormFloat64()*s.Noise
+			if rnd.Float64() < o.SpikeRate {
+				d.Value += math.Copysign(o.SpikeHeight*s.Amplitude, rnd.Float64()-0.5)
+			}
+			d.Value = math.Round(d.Value*1000000) / 1000000
+		}
+
+		if n > 0 {
+			d.Gradient = Gradient(&data[n-1], &d)
+		}
+		data = append(data, d)
+	}
+	return data
+}
+
+//valueAt returns the value of the daily cycle without noise at the local time
+func (s *Series) valueAt(t time.Time) float64 {
+	hour := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+	return s.Base + s.Amplitude*math.Cos((hour-s.PeakHour)/24*2*math.Pi)
+}
+
+//Write writes the model and the data of its sensors to dataPath/sensors/folder in the layout read by LoadModels
+func Write(dataPath string, folder string, m *RoomModel) error {
+	dir := filepath.Join(dataPath, "sensors", folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(dir, "model.json"), func(f io.Writer) error { return WriteModel(f, m) }); err != nil {
+		return err
+	}
+	for i := range m.Sensors {
+		err := writeFile(filepath.Join(dir, DataFileName(i)), func(f io.Writer) error {
+			return WriteData(f, m.Sensors[i].Data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(name string, write func(f io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+//Magic are the first bytes of every binary glTF (GLB) file
+var Magic = []byte("glTF")
+
+const (
+	headerLength   = 12
+	chunkJSON      = 0x4E4F534A
+	chunkHeaderLen = 8
+)
+
+//Document contains the parts of a glTF document needed to identify its meshes
+//https://github.com/KhronosGroup/glTF/tree/master/specification/2.0
+type Document struct {
+	Asset *struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	Nodes []struct {
+		Name string `json:"name"`
+		Mesh *int   `json:"mesh"`
+	} `json:"nodes"`
+	Meshes []struct {
+		Name string `json:"name"`
+	} `json:"meshes"`
+}
+
+//Mesh is a node of the glTF document which references a mesh; its id is the index of the node
+type Mesh struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	MeshIndex int    `json:"mesh_index"`
+	MeshName  string `json:"mesh_name"`
+}
+
+//IsBinary reports whether the content starts like a GLB file
+func IsBinary(content []byte) bool {
+	return bytes.HasPrefix(content, Magic)
+}
+
+//Parse reads either a glTF JSON document or a GLB file
+func Parse(content []byte) (*Document, error) {
+	if IsBinary(content) {
+		var err error
+		if content, err = jsonChunk(content); err != nil {
+			return nil, err
+		}
+	}
+
+	var d Document
+	if err := json.Unmarshal(content, &d); err != nil {
+		return nil, err
+	}
+	if d.Asset == nil {
+		return nil, errors.New("glTF asset is missing")
+	}
+	return &d, nil
+}
+
+//MeshNodes returns all nodes which reference a mesh
+func (d *Document) MeshNodes() []Mesh {
+	meshes := make([]Mesh, 0)
+	for i, n := range d.Nodes {
+		if n.Mesh == nil {
+			continue
+		}
+
+		m := Mesh{ID: int64(i), Name: n.Name, MeshIndex: *n.Mesh}
+		if *n.Mesh >= 0 && *n.Mesh < len(d.Meshes) {
+			m.MeshName = d.Meshes[*n.Mesh].Name
+		}
+		meshes = append(meshes, m)
+	}
+	return meshes
+}
+
+//jsonChunk returns the content of the first chunk of a GLB file which has to contain the JSON document
+func jsonChunk(content []byte) ([]byte, error) {
+	if len(content) < headerLength+chunkHeaderLen {
+		return nil, errors.New("GLB file is too short")
+	}
+
+	length := binary.LittleEndian.Uint32(content[headerLength:])
+	chunkType := binary.LittleEndian.Uint32(content[headerLength+4:])
+	start := headerLength + chunkHeaderLen
+	if chunkType != chunkJSON || uint64(start)+uint64(length) > uint64(len(content)) {
+		return nil, errors.New("GLB file does not start with a JSON chunk")
+	}
+
+	return content[start : start+int(length)], nil
+}
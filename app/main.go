@@ -1,30 +1,89 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	. "github.com/vi-sense/vi-sense/app/api"
+	"github.com/vi-sense/vi-sense/app/config"
 	_ "github.com/vi-sense/vi-sense/app/docs"
+	"github.com/vi-sense/vi-sense/app/generator"
 	. "github.com/vi-sense/vi-sense/app/model"
+	. "github.com/vi-sense/vi-sense/app/storage"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"time"
 )
 
 func main() {
-	SetupDatabase(false)
-	//LoadModels("/sample-data", []string{"berlin", "cape-town", "puerto-natales"}, -1)
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		if err := generate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	c, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("[i] configuration:")
+	c.Dump(os.Stdout)
+
+	SetupDatabase(c.Database, false)
+	SetupStorage(c.Storage.Dir)
+	//LoadModels(c.DataDir, []string{"berlin", "cape-town", "puerto-natales"}, -1)
 	//check if bind mount is working
-	dat, err := ioutil.ReadFile("/sample-data/info.txt")
+	dat, err := ioutil.ReadFile(filepath.Join(c.DataDir, "info.txt"))
 	if err != nil {
 		panic(err)
 	}
 
 	fmt.Print(string(dat))
 
-	r := SetupRouter()
-	// Listen and Server in 0.0.0.0:8080
-	err = r.RunTLS(":44344", "/certs/live/visense.f4.htw-berlin.de/fullchain.pem", "/certs/live/visense.f4.htw-berlin.de/privkey.pem")
-	if err != nil {
-		log.Println(err)
-		log.Fatal(r.Run(":8080"))
+	log.Fatal(Serve(c.Server, NewRouter(c)))
+}
+
+//generate writes synthetic heating system models in the layout read by LoadModels, e.g.
+//app generate -out ./sample-data -models 3 -days 14
+func generate(args []string) error {
+	o := generator.DefaultOptions()
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	out := flags.String("out", "sample-data", "directory the sensors folder is created in")
+	models := flags.Int("models", 1, "number of models")
+	days := flags.Int("days", 7, "number of days of data")
+	start := flags.String("start", o.Start.Format("2006-01-02"), "date of the first reading")
+	flags.Int64Var(&o.Seed, "seed", o.Seed, "seed of the random values")
+	flags.DurationVar(&o.Interval, "interval", o.Interval, "time between two readings")
+	flags.Float64Var(&o.SpikeRate, "spikes", o.SpikeRate, "probability of a spike per reading")
+	flags.Float64Var(&o.FlatlineRate, "flatlines", o.FlatlineRate, "probability of a flatline per reading")
+	flags.Float64Var(&o.GapRate, "gaps", o.GapRate, "probability of a gap per reading")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	if o.Start, err = time.Parse("2006-01-02", *start); err != nil {
+		return fmt.Errorf("invalid start date %s", *start)
+	}
+	if *models < 1 || *days < 1 || o.Interval <= 0 {
+		return fmt.Errorf("models, days and interval have to be positive")
+	}
+	o.Duration = time.Duration(*days) * 24 * time.Hour
+	if o.Jitter >= o.Interval {
+		o.Jitter = 0
+	}
+
+	for n := 0; n < *models; n++ {
+		m := generator.Model(n, o)
+		folder := FolderName(m.Name)
+		if err := generator.Write(*out, folder, m); err != nil {
+			return err
+		}
+		fmt.Printf("[✓] generated model %s in %s/sensors/%s\n", m.Name, *out, folder)
 	}
+	return nil
 }
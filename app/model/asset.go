@@ -0,0 +1,30 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+//Asset specifies a piece of equipment inside a RoomModel like a boiler or a pump to which sensors can be attached
+type Asset struct {
+	ID           uint       `json:"id"`
+	RoomModelID  uint       `json:"room_model_id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type" example:"boiler"`
+	Manufacturer string     `json:"manufacturer"`
+	InstallDate  *time.Time `json:"install_date"`
+	MeshID       *int64     `json:"mesh_id"`
+	Sensors      []Sensor   `json:"sensors,omitempty"`
+}
+
+//Validate checks the editable fields of the asset
+func (a *Asset) Validate() error {
+	if strings.TrimSpace(a.Name) == "" {
+		return errors.New("'name' must not be empty.")
+	}
+	if strings.TrimSpace(a.Type) == "" {
+		return errors.New("'type' must not be empty.")
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+)
+
+//AuditAction is the kind of change an AuditEntry records
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+//AuditEntry records a single change made through the API
+type AuditEntry struct {
+	ID uint `json:"id"`
+	// Actor is the authenticated principal or the address of the client if authentication is disabled
+	Actor    string      `json:"actor" example:"dashboard"`
+	Date     time.Time   `json:"date"`
+	Method   string      `json:"method" example:"PATCH"`
+	Path     string      `json:"path" example:"/sensors/1"`
+	Entity   string      `json:"entity" gorm:"index:idx_audit_entity" example:"sensor"`
+	EntityID uint        `json:"entity_id" gorm:"index:idx_audit_entity" example:"1"`
+	Action   AuditAction `json:"action" example:"update"`
+	// Before and After are the entity before and after the change, Before is null for created entities and After
+	// for deleted ones
+	Before JSONText `json:"before" gorm:"type:text" swaggertype:"object"`
+	After  JSONText `json:"after" gorm:"type:text" swaggertype:"object"`
+}
+
+//JSONText is a JSON document stored as text, it is embedded unchanged when marshalled
+type JSONText string
+
+//MarshalJSON returns the document or null if it is empty
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+//UnmarshalJSON stores the document, null is stored as empty text
+func (j *JSONText) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*j = ""
+	} else {
+		*j = JSONText(b)
+	}
+	return nil
+}
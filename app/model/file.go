@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+//FileKind distinguishes the files which can be uploaded for a RoomModel
+type FileKind string
+
+const (
+	//ModelFileKind is a 3D model in the glTF or GLB format
+	ModelFileKind FileKind = "model"
+	//ImageFileKind is a preview image of the RoomModel
+	ImageFileKind FileKind = "image"
+)
+
+//ModelFile specifies an uploaded file which belongs to a RoomModel; the content itself is kept in the file storage
+type ModelFile struct {
+	ID          uint      `json:"id"`
+	RoomModelID uint      `json:"room_model_id"`
+	Kind        FileKind  `json:"kind"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	Key         string    `json:"-" gorm:"column:storage_key"`
+	Url         string    `json:"url" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
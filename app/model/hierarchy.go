@@ -0,0 +1,56 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+//RoomKind distinguishes enclosed rooms from zones spanning several areas of a floor
+type RoomKind string
+
+const (
+	RoomKindRoom RoomKind = "room"
+	RoomKindZone RoomKind = "zone"
+)
+
+//Floor specifies a single level of a RoomModel
+type Floor struct {
+	ID          uint   `json:"id"`
+	RoomModelID uint   `json:"room_model_id"`
+	Name        string `json:"name"`
+	Level       int    `json:"level"`
+	Rooms       []Room `json:"rooms"`
+}
+
+//Room specifies a room or zone on a Floor to which sensors can be assigned
+type Room struct {
+	ID      uint     `json:"id"`
+	FloorID uint     `json:"floor_id"`
+	Name    string   `json:"name"`
+	Kind    RoomKind `json:"kind"`
+	Sensors []Sensor `json:"sensors,omitempty"`
+}
+
+//Validate checks the editable fields of the floor
+func (f *Floor) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("'name' must not be empty.")
+	}
+	return nil
+}
+
+//Validate checks the editable fields of the room, an empty kind defaults to room
+func (r *Room) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("'name' must not be empty.")
+	}
+
+	switch r.Kind {
+	case "":
+		r.Kind = RoomKindRoom
+	case RoomKindRoom, RoomKindZone:
+	default:
+		return errors.New("'kind' has to be either room or zone.")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+)
+
+//Recurrence defines how often a MaintenanceWindow repeats itself
+type Recurrence string
+
+const (
+	Once   Recurrence = ""
+	Daily  Recurrence = "daily"
+	Weekly Recurrence = "weekly"
+)
+
+//MaintenanceWindow specifies a planned period during which anomalies of either a whole RoomModel or a single Sensor
+//are expected; recurring windows repeat daily or weekly starting with StartDate until Until (if set)
+type MaintenanceWindow struct {
+	ID          uint       `json:"id"`
+	RoomModelID *uint      `json:"room_model_id"`
+	SensorID    *uint      `json:"sensor_id"`
+	Description string     `json:"description"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     time.Time  `json:"end_date"`
+	Recurrence  Recurrence `json:"recurrence"`
+	Until       *time.Time `json:"until"`
+}
+
+//Period returns the interval in which the window repeats itself or zero for one-off windows
+func (w *MaintenanceWindow) Period() time.Duration {
+	switch w.Recurrence {
+	case Daily:
+		return 24 * time.Hour
+	case Weekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+//Covers reports whether t lies within the window or one of its recurrences
+func (w *MaintenanceWindow) Covers(t time.Time) bool {
+	if t.Before(w.StartDate) {
+		return false
+	}
+
+	period := w.Period()
+	if period == 0 {
+		return !t.After(w.EndDate)
+	}
+
+	if w.Until != nil && t.After(*w.Until) {
+		return false
+	}
+
+	return t.Sub(w.StartDate)%period <= w.EndDate.Sub(w.StartDate)
+}
+
+//MaintenanceOccurrence is a single period of a possibly recurring MaintenanceWindow
+type MaintenanceOccurrence struct {
+	Window    MaintenanceWindow `json:"window"`
+	StartDate time.Time         `json:"start_date"`
+	EndDate   time.Time         `json:"end_date"`
+}
+
+//Next returns the first occurrence of the window which has not ended at t, ok is false if there is none
+func (w *MaintenanceWindow) Next(t time.Time) (o MaintenanceOccurrence, ok bool) {
+	duration := w.EndDate.Sub(w.StartDate)
+	start := w.StartDate
+	if period := w.Period(); period != 0 && t.After(w.StartDate) {
+		start = w.StartDate.Add(t.Sub(w.StartDate) / period * period)
+		if !start.Add(duration).After(t) {
+			start = start.Add(period)
+		}
+		if w.Until != nil && start.After(*w.Until) {
+			return o, false
+		}
+	}
+
+	if !start.Add(duration).After(t) {
+		return o, false
+	}
+	return MaintenanceOccurrence{Window: *w, StartDate: start, EndDate: start.Add(duration)}, true
+}
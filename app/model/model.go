@@ -2,12 +2,17 @@ package model
 
 import (
 	"bufio"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +20,7 @@ import (
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/vi-sense/vi-sense/app/config"
 )
 
 type Location struct {
@@ -32,7 +38,44 @@ type RoomModel struct {
 	ImageUrl string   `json:"image_url"`
 	Type     string   `json:"type"`
 	Location Location `json:"location" gorm:"embedded"`
+	// Floors is the number of levels, it equals the number of Floor records once floors were created
 	Floors   int      `json:"floors"`
+	TimeZone string   `json:"time_zone" example:"Europe/Berlin"`
+	Tags     []Tag    `json:"tags,omitempty" gorm:"polymorphic:Owner"`
+	// Distance is the distance in km to the point of a geospatial search
+	Distance *float64 `json:"distance_km,omitempty" gorm:"-"`
+}
+
+//TimeLocation returns the time zone of the site or UTC if none or an unknown one is set
+func (m *RoomModel) TimeLocation() *time.Location {
+	loc, err := time.LoadLocation(m.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+//Validate checks the editable fields of the model
+func (m *RoomModel) Validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return errors.New("'name' must not be empty.")
+	}
+	if strings.TrimSpace(m.Type) == "" {
+		return errors.New("'type' must not be empty.")
+	}
+	if m.Floors < 1 {
+		return fmt.Errorf("'floors' has to be at least 1 value=%d.", m.Floors)
+	}
+	if m.Location.Latitude < -90 || m.Location.Latitude > 90 {
+		return fmt.Errorf("'latitude' is out of its range [-90, 90] value=%g.", m.Location.Latitude)
+	}
+	if m.Location.Longitude < -180 || m.Location.Longitude > 180 {
+		return fmt.Errorf("'longitude' is out of its range [-180, 180] value=%g.", m.Location.Longitude)
+	}
+	if _, err := time.LoadLocation(m.TimeZone); err != nil {
+		return fmt.Errorf("Unknown 'time_zone' value=%s.", m.TimeZone)
+	}
+	return nil
 }
 
 //Sensor specifies the structure for a single sensor which is located inside a RoomModel
@@ -42,6 +85,8 @@ type Sensor struct {
 	LatestData      Data     `json:"latest_data" gorm:"-"`
 	Data            []Data   `json:"-"`
 	ImportName      string   `json:"import_name,omitempty" gorm:"-"`
+	RoomID          *uint    `json:"room_id"`
+	AssetID         *uint    `json:"asset_id"`
 	MeshID          *int64   `json:"mesh_id"`
 	Name            string   `json:"name"`
 	Description     string   `json:"description"`
@@ -50,6 +95,7 @@ type Sensor struct {
 	UpperBound      *float64 `json:"upper_bound"`
 	LowerBound      *float64 `json:"lower_bound"`
 	GradientBound   *float64 `json:"gradient_bound"`
+	Tags            []Tag    `json:"tags,omitempty" gorm:"polymorphic:Owner"`
 }
 
 //Data specifies the structure for a single measured value w/ timestamp which was recorded by a sensor
@@ -78,32 +124,36 @@ const Layout = "2006-01-02 15:04:05"
 
 var DB *gorm.DB
 
+//schema contains all structures which are mapped to tables
+var schema = []interface{}{
+	&RoomModel{}, &Sensor{}, &Data{}, &MaintenanceWindow{}, &BoundSchedule{}, &ModelFile{}, &Floor{}, &Room{},
+	&Asset{}, &Tag{}, &User{}, &Grant{}, &AuditEntry{}, &SensorConfig{},
+}
+
 //SetupDatabase initializes the database w/ the orm mapping and postgres as the dialect;
 //drop defines whether or not the currently active scheme should be dropped
-func SetupDatabase(drop bool) {
-	// TODO check if ssl mode can be enabled later
-	dbInfo := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
-		os.Getenv("POSTGRES_HOST"), os.Getenv("POSTGRES_PORT"), os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_DB"), os.Getenv("POSTGRES_PASSWORD"))
+func SetupDatabase(c config.Database, drop bool) {
+	dbInfo := fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Name, c.Password, c.SSLMode)
 
 	var err error
 	DB, err = gorm.Open("postgres", dbInfo)
 
 	if err != nil {
 		fmt.Println(err)
-		fmt.Println(dbInfo)
+		fmt.Printf("host=%s port=%d user=%s dbname=%s\n", c.Host, c.Port, c.User, c.Name)
 		panic("[!] failed to connect to db")
 	} else {
 		fmt.Println("[✓] successfully connected to db")
 	}
 
 	if drop {
-		DB.DropTableIfExists(&RoomModel{}, &Sensor{}, &Data{}, &Location{})
+		DB.DropTableIfExists(append(schema, &Location{})...)
 		fmt.Println("[✓] all data successfully dropped")
 	}
 
 	// Migrate the Schema
-	DB.AutoMigrate(&RoomModel{}, &Sensor{}, &Data{}, &Location{})
+	DB.AutoMigrate(append(schema, &Location{})...)
 	fmt.Println("[✓] schemes migrated")
 }
 
@@ -117,17 +167,15 @@ func LoadModels(dataPath string, modelFolders []string, dataLimit int) {
 		go func(folder string) {
 			defer wg.Done()
 			f, _ := ioutil.ReadFile(fmt.Sprintf("%s/sensors/%s/model.json", dataPath, folder))
-			var m *RoomModel
+			m, err := ReadModel(f, func(name string) (io.ReadCloser, error) {
+				return os.Open(fmt.Sprintf("%s/sensors/%s/%s", dataPath, folder, name))
+			}, dataLimit)
 
-			if err := json.Unmarshal(f, &m); err != nil {
+			if err != nil {
 				fmt.Println("error loading model: ", folder)
 				panic(err)
 			}
 
-			for i := range m.Sensors {
-				m.Sensors[i].Data = loadData(fmt.Sprintf("%s/sensors/%s/%s", dataPath, folder, m.Sensors[i].ImportName), dataLimit)
-			}
-
 			DB.Create(&m)
 			fmt.Printf("[✓] model %s loaded %s\n", folder, time.Now().String())
 		}(folder)
@@ -146,7 +194,7 @@ func SetupTestDatabase() {
 	}
 	DB.DB().SetMaxIdleConns(3)
 	// Migrate the Schema
-	DB.AutoMigrate(&RoomModel{}, &Sensor{}, &Data{})
+	DB.AutoMigrate(schema...)
 }
 
 //DeleteTestDatabase deletes local sqlite db for testing
@@ -158,16 +206,141 @@ func DeleteTestDatabase() {
 	}
 }
 
-func loadData(path string, dataLimit int) []Data {
-	file, err := os.Open(path)
-	if err != nil {
-		fmt.Println("[!] Error loading file")
-		panic(err)
+//ReadModel parses the content of a model.json and loads the data of its sensors from the csv files returned by open,
+//which is called with the import name of each sensor
+func ReadModel(content []byte, open func(name string) (io.ReadCloser, error), dataLimit int) (*RoomModel, error) {
+	var d *modelDescription
+	if err := json.Unmarshal(content, &d); err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, errors.New("model.json does not contain a model")
+	}
+	m := d.model()
+
+	for i := range m.Sensors {
+		f, err := open(m.Sensors[i].ImportName)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Sensors[i].Data, err = ParseData(f, dataLimit)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Sensors[i].ImportName, err)
+		}
+	}
+
+	return m, nil
+}
+
+//modelDescription is the content of a model.json as read by ReadModel
+type modelDescription struct {
+	Name     string              `json:"name"`
+	Url      string              `json:"url"`
+	ImageUrl string              `json:"image_url"`
+	Type     string              `json:"type"`
+	Location Location            `json:"location"`
+	Floors   int                 `json:"floors"`
+	TimeZone string              `json:"time_zone"`
+	Tags     []Tag               `json:"tags,omitempty"`
+	Sensors  []sensorDescription `json:"sensors"`
+}
+
+//sensorDescription contains the configuration of a sensor and the name of the csv file with its data
+type sensorDescription struct {
+	ImportName      string   `json:"import_name"`
+	MeshID          *int64   `json:"mesh_id"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	MeasurementUnit string   `json:"measurement_unit"`
+	Range           string   `json:"range"`
+	UpperBound      *float64 `json:"upper_bound"`
+	LowerBound      *float64 `json:"lower_bound"`
+	GradientBound   *float64 `json:"gradient_bound"`
+	Tags            []Tag    `json:"tags,omitempty"`
+}
+
+//model returns a new room model with the described fields only, so ids and references to other records of a
+//model.json are never taken over
+func (d *modelDescription) model() *RoomModel {
+	m := &RoomModel{
+		Name:     d.Name,
+		Url:      d.Url,
+		ImageUrl: d.ImageUrl,
+		Type:     d.Type,
+		Location: d.Location,
+		Floors:   d.Floors,
+		TimeZone: d.TimeZone,
+		Tags:     d.Tags,
+		Sensors:  make([]Sensor, len(d.Sensors)),
+	}
+	for i, s := range d.Sensors {
+		m.Sensors[i] = Sensor{
+			ImportName:      s.ImportName,
+			MeshID:          s.MeshID,
+			Name:            s.Name,
+			Description:     s.Description,
+			MeasurementUnit: s.MeasurementUnit,
+			Range:           s.Range,
+			UpperBound:      s.UpperBound,
+			LowerBound:      s.LowerBound,
+			GradientBound:   s.GradientBound,
+			Tags:            s.Tags,
+		}
+	}
+	return m
+}
+
+//WriteModel writes the model with the configuration of its sensors as model.json read by ReadModel, the data of the
+//n-th sensor is expected in the csv file named DataFileName(n)
+func WriteModel(w io.Writer, m *RoomModel) error {
+	d := modelDescription{
+		Name:     m.Name,
+		Url:      m.Url,
+		ImageUrl: m.ImageUrl,
+		Type:     m.Type,
+		Location: m.Location,
+		Floors:   m.Floors,
+		TimeZone: m.TimeZone,
+		Tags:     m.Tags,
+		Sensors:  make([]sensorDescription, len(m.Sensors)),
+	}
+	for i, s := range m.Sensors {
+		d.Sensors[i] = sensorDescription{
+			ImportName:      DataFileName(i),
+			MeshID:          s.MeshID,
+			Name:            s.Name,
+			Description:     s.Description,
+			MeasurementUnit: s.MeasurementUnit,
+			Range:           s.Range,
+			UpperBound:      s.UpperBound,
+			LowerBound:      s.LowerBound,
+			GradientBound:   s.GradientBound,
+			Tags:            s.Tags,
+		}
 	}
-	defer file.Close()
+	return json.NewEncoder(w).Encode(&d)
+}
+
+var folderName = regexp.MustCompile("[^a-z0-9]+")
+
+//FolderName returns the name of the folder below sensors/ for a model with the name in the layout read by LoadModels,
+//it is empty if the name contains no letters or digits
+func FolderName(name string) string {
+	return strings.Trim(folderName.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+//DataFileName returns the name of the csv file with the data of the n-th sensor (counting from 0) of a model.json
+func DataFileName(n int) string {
+	return fmt.Sprintf("s%d.csv", n+1)
+}
 
+//ParseData reads at most dataLimit values of a csv file with the columns value and date (unix time),
+//a negative limit reads all values
+func ParseData(r io.Reader, dataLimit int) ([]Data, error) {
 	data := make([]Data, 0)
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	scanner.Scan()
 	headerText := scanner.Text()
 
@@ -180,7 +353,7 @@ func loadData(path string, dataLimit int) []Data {
 		var d []Data
 		combined := fmt.Sprintf("%s\n%s", headerText, scanner.Text())
 		if err := gocsv.UnmarshalString(combined, &d); err != nil {
-			panic(err)
+			return nil, err
 		}
 		data = append(data, d[0])
 
@@ -191,12 +364,39 @@ func loadData(path string, dataLimit int) []Data {
 		i++
 	}
 
-	return data
+	return data, scanner.Err()
+}
+
+//WriteData writes the data as csv file in the format read by ParseData
+func WriteData(w io.Writer, data []Data) error {
+	c := csv.NewWriter(w)
+	if err := c.Write([]string{"value", "date"}); err != nil {
+		return err
+	}
+	for i := range data {
+		err := c.Write([]string{strconv.FormatFloat(data[i].Value, 'f', -1, 64),
+			strconv.FormatInt(data[i].Date.Unix(), 10)})
+		if err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+//Gradient returns the change per second from the previous reading to d rounded to 5 decimal places, readings within
+//the same second have no gradient
+func Gradient(previous *Data, d *Data) float64 {
+	grad, _, _ := calculateGradient(previous, d)
+	return grad
 }
 
 func calculateGradient(d1 *Data, d2 *Data) (float64, float64, int64) {
 	d := d2.Value - d1.Value
 	dTime := d2.Date.Unix() - d1.Date.Unix()
+	if dTime == 0 {
+		return 0, d, dTime
+	}
 	grad := d / float64(dTime)
 	grad = math.Round(grad*100000) / 100000
 	return grad, d, dTime
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+//TimeOfDayLayout is the layout of the start and end times of a BoundSchedule
+const TimeOfDayLayout = "15:04"
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+//BoundSchedule overrides the bounds of a Sensor during a daily time range on selected weekdays, e.g. for the night
+//setback of a heating system; times are interpreted in the time zone of the sensor's RoomModel and ranges whose end
+//lies before their start reach into the following day. Like a SensorConfig the schedule is in force from ValidFrom until
+//ValidTo, a schedule without ValidFrom applies to all readings before ValidTo
+type BoundSchedule struct {
+	ID            uint     `json:"id"`
+	SensorID      uint     `json:"sensor_id"`
+	Name          string   `json:"name"`
+	Weekdays      string   `json:"weekdays" example:"mon,tue,wed,thu,fri"`
+	StartTime     string   `json:"start_time" example:"22:00"`
+	EndTime       string   `json:"end_time" example:"06:00"`
+	LowerBound    *float64 `json:"lower_bound"`
+	UpperBound    *float64 `json:"upper_bound"`
+	GradientBound *float64 `json:"gradient_bound"`
+	ValidFrom     *time.Time `json:"valid_from"`
+	ValidTo       *time.Time `json:"valid_to"`
+}
+
+//Validate checks the time range and the weekdays of the schedule
+func (b *BoundSchedule) Validate() error {
+	if _, err := time.Parse(TimeOfDayLayout, b.StartTime); err != nil {
+		return fmt.Errorf("Error parsing 'start_time' with value '%s'.", b.StartTime)
+	}
+	if _, err := time.Parse(TimeOfDayLayout, b.EndTime); err != nil {
+		return fmt.Errorf("Error parsing 'end_time' with value '%s'.", b.EndTime)
+	}
+	if _, err := parseWeekdays(b.Weekdays); err != nil {
+		return err
+	}
+	return nil
+}
+
+//Applies reports whether the schedule was in force at t and t lies within the scheduled time range; loc is the time
+//zone of the site
+func (b *BoundSchedule) Applies(t time.Time, loc *time.Location) bool {
+	if (b.ValidFrom != nil && t.Before(*b.ValidFrom)) || (b.ValidTo != nil && !t.Before(*b.ValidTo)) {
+		return false
+	}
+
+	start, err := time.Parse(TimeOfDayLayout, b.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(TimeOfDayLayout, b.EndTime)
+	if err != nil {
+		return false
+	}
+	days, err := parseWeekdays(b.Weekdays)
+	if err != nil {
+		return false
+	}
+
+	t = t.In(loc)
+	m := t.Hour()*60 + t.Minute()
+	s := start.Hour()*60 + start.Minute()
+	e := end.Hour()*60 + end.Minute()
+
+	switch {
+	case s == e:
+		return days[t.Weekday()]
+	case s < e:
+		return m >= s && m < e && days[t.Weekday()]
+	case m >= s:
+		return days[t.Weekday()]
+	case m < e:
+		// the range started on the previous day
+		return days[(t.Weekday()+6)%7]
+	}
+	return false
+}
+
+//parseWeekdays parses a comma separated list of abbreviated weekdays; an empty list includes every day
+func parseWeekdays(s string) ([7]bool, error) {
+	var days [7]bool
+	if strings.TrimSpace(s) == "" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+
+	for _, d := range strings.Split(s, ",") {
+		found := false
+		for i, w := range weekdays {
+			if strings.ToLower(strings.TrimSpace(d)) == w {
+				days[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return days, fmt.Errorf("Error parsing 'weekdays' with value '%s'.", s)
+		}
+	}
+	return days, nil
+}
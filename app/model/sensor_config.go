@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+//SensorConfig is a version of the bounds and the mesh of a Sensor which was in force from ValidFrom until ValidTo;
+//a version without ValidFrom applies to all readings before ValidTo and the current version has no ValidTo
+type SensorConfig struct {
+	ID            uint       `json:"id"`
+	SensorID      uint       `json:"sensor_id"`
+	ValidFrom     *time.Time `json:"valid_from"`
+	ValidTo       *time.Time `json:"valid_to"`
+	UpperBound    *float64   `json:"upper_bound"`
+	LowerBound    *float64   `json:"lower_bound"`
+	GradientBound *float64   `json:"gradient_bound"`
+	MeshID        *int64     `json:"mesh_id"`
+}
+
+//NewSensorConfig returns a version with the current configuration of the sensor which is valid from the passed date on
+func NewSensorConfig(s *Sensor, from *time.Time) SensorConfig {
+	return SensorConfig{SensorID: s.ID, ValidFrom: from, UpperBound: s.UpperBound, LowerBound: s.LowerBound,
+		GradientBound: s.GradientBound, MeshID: s.MeshID}
+}
+
+//Applies reports whether the version was in force at the passed time
+func (c *SensorConfig) Applies(t time.Time) bool {
+	return (c.ValidFrom == nil || !t.Before(*c.ValidFrom)) && (c.ValidTo == nil || t.Before(*c.ValidTo))
+}
+
+//Differs reports whether the bounds or the mesh of the sensor differ from the version
+func (c *SensorConfig) Differs(s *Sensor) bool {
+	return !equalFloat(c.UpperBound, s.UpperBound) || !equalFloat(c.LowerBound, s.LowerBound) ||
+		!equalFloat(c.GradientBound, s.GradientBound) || !equalInt(c.MeshID, s.MeshID)
+}
+
+func equalFloat(a *float64, b *float64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func equalInt(a *int64, b *int64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
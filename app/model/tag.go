@@ -0,0 +1,34 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Tag is a key/value label of a RoomModel or Sensor, e.g. system=DHW for a sensor or portfolio=north for a building
+type Tag struct {
+	ID        uint   `json:"-"`
+	OwnerID   uint   `json:"-"`
+	OwnerType string `json:"-"`
+	Key       string `json:"key" gorm:"column:tag_key" example:"system"`
+	Value     string `json:"value" example:"heating circuit 2"`
+}
+
+//ValidateTags checks that every tag has a key and that no key is used twice
+func ValidateTags(tags []Tag) error {
+	keys := make(map[string]bool, len(tags))
+	for i := range tags {
+		tags[i].Key = strings.TrimSpace(tags[i].Key)
+		if tags[i].Key == "" {
+			return fmt.Errorf("'key' of tag %d must not be empty.", i)
+		}
+		if strings.Contains(tags[i].Key, ":") {
+			return fmt.Errorf("'key' of tag %d must not contain ':'.", i)
+		}
+		if keys[tags[i].Key] {
+			return fmt.Errorf("Tag '%s' is used more than once.", tags[i].Key)
+		}
+		keys[tags[i].Key] = true
+	}
+	return nil
+}
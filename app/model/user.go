@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+//Role defines what a User is allowed to do with the room models granted to them
+type Role string
+
+const (
+	// RoleViewer may read the granted models
+	RoleViewer Role = "viewer"
+	// RoleTechnician may additionally configure the sensors of the granted models and ingest data
+	RoleTechnician Role = "technician"
+	// RoleAdmin may do everything with all models and manage the users
+	RoleAdmin Role = "admin"
+)
+
+var roleRanks = map[Role]int{RoleViewer: 1, RoleTechnician: 2, RoleAdmin: 3}
+
+//Includes reports whether the role has at least the permissions of the other role
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+//User is a client of the API; the name is the name of the API key or the subject of the bearer token
+type User struct {
+	ID     uint    `json:"id"`
+	Name   string  `json:"name" gorm:"unique_index" example:"property-manager-north"`
+	Role   Role    `json:"role" example:"viewer"`
+	Grants []Grant `json:"grants"`
+}
+
+//Grant permits a User to access a single RoomModel
+type Grant struct {
+	ID          uint `json:"-"`
+	UserID      uint `json:"-"`
+	RoomModelID uint `json:"room_model_id"`
+}
+
+//Validate checks the editable fields of the user
+func (u *User) Validate() error {
+	u.Name = strings.TrimSpace(u.Name)
+	if u.Name == "" {
+		return errors.New("'name' must not be empty.")
+	}
+	if _, ok := roleRanks[u.Role]; !ok {
+		return errors.New("'role' has to be one of viewer, technician or admin.")
+	}
+	return nil
+}
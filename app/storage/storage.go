@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//File is a stored file opened for reading
+type File interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+//Storage abstracts the backend in which uploaded files are kept; keys are slash separated relative paths
+type Storage interface {
+	Save(key string, r io.Reader) (int64, error)
+	Open(key string) (File, error)
+	Delete(key string) error
+}
+
+//Files is the storage backend used for all uploads
+var Files Storage
+
+//SetupStorage initializes the local directory storage backend; the directory is created if it does not exist
+func SetupStorage(dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Println(err)
+		panic("[!] failed to create storage directory")
+	}
+	Files = &LocalStorage{Dir: dir}
+	fmt.Printf("[✓] storing files in %s\n", dir)
+}
+
+//LocalStorage keeps files inside a directory of the local file system
+type LocalStorage struct {
+	Dir string
+}
+
+//Save writes the content of r to the file with the given key, an existing file is replaced
+func (s *LocalStorage) Save(key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+
+	// write to a temporary file first so readers never see incomplete files
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+
+	return n, os.Rename(tmp, path)
+}
+
+//Open opens the file with the given key for reading
+func (s *LocalStorage) Open(key string) (File, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+//Delete removes the file with the given key, missing files are ignored
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid storage key " + key)
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}